@@ -4,10 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"testing"
 
-	utils "github.com/paketo-community/ubi-base-stack/internal/utils"
+	"github.com/paketo-community/ubi-base-stack/internal/images"
 	"github.com/sclevine/spec"
 
 	. "github.com/onsi/gomega"
@@ -16,7 +15,7 @@ import (
 	. "github.com/paketo-buildpacks/occam/matchers"
 )
 
-func testBuildpackIntegration(t *testing.T, context spec.G, it spec.S) {
+func testBuildpackIntegration(t *testing.T, context spec.G, it spec.S, stack images.StackImages, builder builderImages) {
 	var (
 		Expect     = NewWithT(t).Expect
 		Eventually = NewWithT(t).Eventually
@@ -30,9 +29,6 @@ func testBuildpackIntegration(t *testing.T, context spec.G, it spec.S) {
 
 		image     occam.Image
 		container occam.Container
-
-		runImageUrl     string
-		builderImageUrl string
 	)
 
 	it.Before(func() {
@@ -40,14 +36,12 @@ func testBuildpackIntegration(t *testing.T, context spec.G, it spec.S) {
 		docker = occam.NewDocker()
 	})
 
-	context("When building GO app using default stack", func() {
+	context(fmt.Sprintf("When building a GO app using the %s run image", stack.Name), func() {
 
 		it.After(func() {
 			Expect(docker.Container.Remove.Execute(container.ID)).To(Succeed())
 			Expect(docker.Image.Remove.Execute(image.ID)).To(Succeed())
 			Expect(docker.Volume.Remove.Execute(occam.CacheVolumeNames(name))).To(Succeed())
-			err = utils.RemoveImages(docker, []string{runImageUrl, builderImageUrl})
-			Expect(err).NotTo(HaveOccurred())
 			Expect(os.RemoveAll(source)).To(Succeed())
 		})
 
@@ -59,116 +53,40 @@ func testBuildpackIntegration(t *testing.T, context spec.G, it spec.S) {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
-		for _, stack := range settings.ImagesJson.StackImages {
-			stack := stack
-
-			if stack.Name != "default" {
-				continue
-			}
-
-			it("should successfully build a go app", func() {
-				_, runImageUrl, builderImageUrl, err = utils.GenerateBuilder(
-					filepath.Join(root, DefaultStack.OutputDir, "build.oci"),
-					filepath.Join(root, stack.OutputDir, "run.oci"),
-					RegistryUrl,
-				)
-				Expect(err).NotTo(HaveOccurred())
-
-				image, _, err = pack.WithNoColor().Build.
-					WithBuildpacks(
-						settings.Buildpacks.GoDist.Online,
-						settings.Buildpacks.BuildPlan.Online,
-					).
-					WithEnv(map[string]string{
-						"BP_LOG_LEVEL": "DEBUG",
-					}).
-					WithPullPolicy("if-not-present").
-					WithBuilder(builderImageUrl).
-					Execute(name, source)
-				Expect(err).NotTo(HaveOccurred())
-
-				container, err = docker.Container.Run.
-					WithDirect().
-					WithCommand("go").
-					WithCommandArgs([]string{"run", "main.go"}).
-					WithEnv(map[string]string{"PORT": "8080"}).
-					WithPublish("8080").
-					WithPublishAll().
-					Execute(image.ID)
-				Expect(err).NotTo(HaveOccurred())
-
-				Eventually(container).Should(BeAvailable())
-				Eventually(container).Should(Serve(MatchRegexp(`go1.*`)).OnPort(8080))
-
-			})
-		}
-	})
-
-	context("When building a GO app using Node.js stacks", func() {
-
-		it.After(func() {
-			Expect(docker.Container.Remove.Execute(container.ID)).To(Succeed())
-			Expect(docker.Image.Remove.Execute(image.ID)).To(Succeed())
-			Expect(docker.Volume.Remove.Execute(occam.CacheVolumeNames(name))).To(Succeed())
-			err = utils.RemoveImages(docker, []string{runImageUrl, builderImageUrl})
+		it("should successfully build a go app", func() {
+			image, _, err = pack.WithNoColor().Build.
+				WithBuildpacks(
+					settings.Buildpacks.GoDist.Online,
+					settings.Buildpacks.BuildPlan.Online,
+				).
+				WithEnv(map[string]string{
+					"BP_LOG_LEVEL": "DEBUG",
+				}).
+				WithPullPolicy(settings.PullPolicy).
+				WithBuilder(builder.imageUrl).
+				// trusted builders run the lifecycle they ship rather than
+				// the lifecycle image of the same version
+				WithTrustBuilder().
+				WithRunImage(builder.runImageUrls[stack.Name]).
+				Execute(name, source)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(os.RemoveAll(source)).To(Succeed())
-		})
 
-		it.Before(func() {
-			name, err = occam.RandomName()
+			container, err = docker.Container.Run.
+				WithDirect().
+				WithCommand("go").
+				WithCommandArgs([]string{"run", "main.go"}).
+				WithEnv(map[string]string{"PORT": "8080"}).
+				WithPublish("8080").
+				WithPublishAll().
+				Execute(image.ID)
 			Expect(err).NotTo(HaveOccurred())
 
-			source, err = occam.Source(filepath.Join("integration", "testdata", "simple_app"))
-			Expect(err).NotTo(HaveOccurred())
-		})
-
-		nodejsRegex, _ := regexp.Compile("^nodejs")
-
-		for _, stack := range settings.ImagesJson.StackImages {
-			// Create a copy of the stack to get the value instead of a pointer
-			stack := stack
+			Eventually(container).Should(BeAvailable())
+			Eventually(container).Should(Serve(MatchRegexp(`go1.*`)).OnPort(8080))
 
-			if !nodejsRegex.MatchString(stack.Name) {
-				continue
+			if stack.Type == images.TypeNodejs {
+				Eventually(container).Should(Serve(MatchRegexp(fmt.Sprintf(`v%s.*`, stack.RuntimeVersion))).OnPort(8080).WithEndpoint("/nodejs/version"))
 			}
-
-			it(fmt.Sprintf("it should successfully get the %s version of the run image", stack.Name), func() {
-				_, runImageUrl, builderImageUrl, err = utils.GenerateBuilder(
-					filepath.Join(root, DefaultStack.OutputDir, "build.oci"),
-					filepath.Join(root, stack.OutputDir, "run.oci"),
-					RegistryUrl,
-				)
-				Expect(err).NotTo(HaveOccurred())
-
-				image, _, err = pack.WithNoColor().Build.
-					WithBuildpacks(
-						settings.Buildpacks.GoDist.Online,
-						settings.Buildpacks.BuildPlan.Online,
-					).
-					WithEnv(map[string]string{
-						"BP_LOG_LEVEL": "DEBUG",
-					}).
-					WithPullPolicy("if-not-present").
-					WithBuilder(builderImageUrl).
-					Execute(name, source)
-				Expect(err).NotTo(HaveOccurred())
-
-				container, err = docker.Container.Run.
-					WithDirect().
-					WithCommand("go").
-					WithCommandArgs([]string{"run", "main.go"}).
-					WithEnv(map[string]string{"PORT": "8080"}).
-					WithPublish("8080").
-					WithPublishAll().
-					Execute(image.ID)
-				Expect(err).NotTo(HaveOccurred())
-
-				Eventually(container).Should(BeAvailable())
-				Eventually(container).Should(Serve(MatchRegexp(`go1.*`)).OnPort(8080))
-				nodejsMajorVersion := stack.Name[len("nodejs-"):]
-				Eventually(container).Should(Serve(MatchRegexp(fmt.Sprintf(`v%s.*`, nodejsMajorVersion))).OnPort(8080).WithEndpoint("/nodejs/version"))
-			})
-		}
+		})
 	})
 }
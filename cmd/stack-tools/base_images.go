@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/paketo-community/ubi-base-stack/internal/descriptor"
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+)
+
+func runBaseImages(args []string) error {
+	flags := flag.NewFlagSet("base-images", flag.ContinueOnError)
+	root := flags.String("root", ".", "path to the root of the stack repository")
+	fix := flags.String("fix", "", "side to rewrite when the base images disagree, either dockerfile or images")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	imagesJson, err := images.Load(*root)
+	if err != nil {
+		return err
+	}
+
+	switch *fix {
+	case "":
+		return descriptor.CheckBaseImages(*root, imagesJson)
+	case "dockerfile", "images":
+	default:
+		return fmt.Errorf("--fix must be either dockerfile or images, found %q", *fix)
+	}
+
+	baseImages, err := descriptor.BaseImages(*root, imagesJson)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(*root, images.Filename)
+	document, err := images.OpenDocument(path)
+	if err != nil {
+		return err
+	}
+
+	for _, baseImage := range baseImages {
+		inSync, err := baseImage.InSync()
+		if err != nil {
+			return err
+		}
+
+		if inSync {
+			continue
+		}
+
+		if *fix == "dockerfile" {
+			err = baseImage.SyncDockerfile(*root)
+		} else {
+			err = document.Update(baseImage.Name, baseImage.Field, descriptor.DockerTransport+baseImage.From)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if *fix == "images" {
+		return document.Write(path)
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/paketo-community/ubi-base-stack/internal/utils"
+)
+
+// stringList collects repeated flags.
+type stringList []string
+
+func (s *stringList) String() string {
+	return fmt.Sprint(*s)
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+func runBuilder(args []string) error {
+	flags := flag.NewFlagSet("builder", flag.ContinueOnError)
+	root := flags.String("root", ".", "path to the root of the stack repository")
+	name := flags.String("name", "", "images.json entry whose distro provides the build image")
+	lifecycleVersion := flags.String("lifecycle-version", "", "lifecycle release to download")
+	lifecycleURI := flags.String("lifecycle-uri", "", "path or URL of a lifecycle archive, {arch} is replaced with the lifecycle architecture")
+	allPlatforms := flags.Bool("all-platforms", false, "create a builder for every platform of the build image")
+	description := flags.String("description", "", "description of the builder")
+	output := flags.String("output", "", "path of the OCI archive to write the builder to")
+	publish := flags.String("publish", "", "reference to push the builder to")
+	var platforms, runImages, buildpacks, order, extensions, orderExtensions stringList
+	flags.Var(&platforms, "platform", "os/arch of a builder to create, the host platform when omitted (repeatable)")
+	flags.Var(&runImages, "run-image", "reference of a run image of the builder, the first one is the default (repeatable)")
+	flags.Var(&buildpacks, "buildpack", "buildpack directory, tarball or docker:// buildpackage (repeatable)")
+	flags.Var(&order, "order", "comma separated buildpack ids of an order group, ids ending with ? are optional (repeatable)")
+	flags.Var(&extensions, "extension", "extension directory, tarball or docker:// buildpackage (repeatable)")
+	flags.Var(&orderExtensions, "order-extensions", "comma separated extension ids of an order group, ids ending with ? are optional (repeatable)")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *name == "" || len(runImages) == 0 {
+		return errors.New("both --name and --run-image must be provided")
+	}
+
+	if *allPlatforms && len(platforms) > 0 {
+		return errors.New("--platform and --all-platforms are mutually exclusive")
+	}
+
+	if (*output == "") == (*publish == "") {
+		return errors.New("exactly one of --output or --publish must be provided")
+	}
+
+	imagesJson, err := images.Load(*root)
+	if err != nil {
+		return err
+	}
+
+	stack, err := imagesJson.Lookup(*name)
+	if err != nil {
+		return err
+	}
+
+	distro, err := imagesJson.Distro(stack)
+	if err != nil {
+		return err
+	}
+
+	buildStack, err := imagesJson.BuildStack(stack.Distro)
+	if err != nil {
+		return err
+	}
+
+	options := utils.BuilderOptions{
+		StackID:     distro.StackID,
+		Description: *description,
+		Lifecycle:   utils.BuilderLifecycle{Version: *lifecycleVersion, URI: *lifecycleURI},
+		Platforms:   platforms,
+		Assembler:   utils.AssemblerGo,
+	}
+
+	for _, image := range runImages {
+		options.RunImages = append(options.RunImages, utils.BuilderRunImage{Image: image})
+	}
+
+	for _, uri := range buildpacks {
+		options.Buildpacks = append(options.Buildpacks, utils.BuilderModule{URI: uri})
+	}
+
+	for _, uri := range extensions {
+		options.Extensions = append(options.Extensions, utils.BuilderModule{URI: uri})
+	}
+
+	options.Order = orderGroups(order)
+	options.OrderExtensions = orderGroups(orderExtensions)
+
+	archive, err := utils.OpenArchive(buildStack.BuildArchive(*root))
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	if *allPlatforms {
+		manifest, err := archive.Index.IndexManifest()
+		if err != nil {
+			return err
+		}
+
+		for _, descriptor := range manifest.Manifests {
+			if descriptor.Platform != nil {
+				options.Platforms = append(options.Platforms, descriptor.Platform.String())
+			}
+		}
+	}
+
+	if len(options.Platforms) == 0 {
+		p, err := utils.ParsePlatform("")
+		if err != nil {
+			return err
+		}
+		options.Platforms = []string{p.String()}
+	}
+
+	for _, platform := range options.Platforms {
+		p, err := utils.ParsePlatform(platform)
+		if err != nil {
+			return err
+		}
+		options.Targets = append(options.Targets, utils.DistroTarget(distro, p))
+	}
+
+	builder, err := utils.AssembleBuilderIndex(archive.Index, options)
+	if err != nil {
+		return fmt.Errorf("%s: %w", buildStack.BuildArchive(*root), err)
+	}
+
+	if *publish != "" {
+		return utils.PublishIndex(*publish, builder)
+	}
+
+	return utils.WriteArchive(*output, builder)
+}
+
+// orderGroups parses --order values, comma separated ids of which those
+// ending with ? are optional.
+func orderGroups(values []string) []utils.OrderGroup {
+	var groups []utils.OrderGroup
+	for _, ids := range values {
+		var group utils.OrderGroup
+		for _, id := range strings.Split(ids, ",") {
+			id, optional := strings.CutSuffix(strings.TrimSpace(id), "?")
+			group = append(group, utils.OrderEntry{ID: id, Optional: optional})
+		}
+		groups = append(groups, group)
+	}
+
+	return groups
+}
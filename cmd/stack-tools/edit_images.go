@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+)
+
+// keyValues collects repeated --set key=value flags.
+type keyValues [][2]string
+
+func (k *keyValues) String() string {
+	return fmt.Sprint(*k)
+}
+
+func (k *keyValues) Set(value string) error {
+	key, v, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("expected key=value, found %q", value)
+	}
+	*k = append(*k, [2]string{key, v})
+	return nil
+}
+
+func runEditImages(args []string) error {
+	operations := map[string]bool{"add": true, "update": true, "remove": true, "move": true, "default-run": true, "set": true}
+	if len(args) < 1 || !operations[args[0]] {
+		return fmt.Errorf("usage: stack-tools edit-images add|update|remove|move|default-run|set [OPTIONS]")
+	}
+
+	flags := flag.NewFlagSet("edit-images "+args[0], flag.ContinueOnError)
+	root := flags.String("root", ".", "path to the root of the stack repository")
+	name := flags.String("name", "", "name of the entry to edit")
+	entry := flags.String("entry", "", "JSON object of the entry to add")
+	index := flags.Int("index", -1, "position to add or move the entry to")
+	var values keyValues
+	flags.Var(&values, "set", "key=value to assign, values are JSON unless the field is a string (repeatable)")
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(*root, images.Filename)
+	document, err := images.OpenDocument(path)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "add":
+		var stack images.StackImages
+		stack, err = images.ParseEntry([]byte(*entry))
+		if err != nil {
+			return fmt.Errorf("failed to parse --entry: %w", err)
+		}
+		err = document.Add(stack, *index)
+
+	case "update":
+		for _, kv := range values {
+			var v any
+			v, err = images.EntryValue(kv[0], kv[1])
+			if err != nil {
+				break
+			}
+
+			err = document.Update(*name, kv[0], v)
+			if err != nil {
+				break
+			}
+		}
+
+	case "remove":
+		err = document.Remove(*name)
+
+	case "move":
+		err = document.Move(*name, *index)
+
+	case "default-run":
+		err = document.SetDefaultRunImage(*name)
+
+	case "set":
+		for _, kv := range values {
+			var v any
+			v, err = images.TopLevelValue(kv[0], kv[1])
+			if err != nil {
+				break
+			}
+
+			err = document.Set(kv[0], v)
+			if err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	imagesJson, err := document.ImagesJson()
+	if err != nil {
+		return err
+	}
+
+	err = imagesJson.Validate(*root)
+	if err != nil {
+		return err
+	}
+
+	return document.Write(path)
+}
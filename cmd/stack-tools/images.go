@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+)
+
+func runImages(args []string) error {
+	flags := flag.NewFlagSet("images", flag.ContinueOnError)
+	root := flags.String("root", ".", "path to the root of the stack repository")
+	expression := flags.String("select", "", "selector of the entries to print, e.g. 'nodejs-* !nodejs-16 type=java default-run'")
+	builderStacks := flags.Bool("with-builder-stacks", false, "also print the build and default run entries of the distros of the selected entries")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	imagesJson, err := images.Load(*root)
+	if err != nil {
+		return err
+	}
+
+	selector, err := images.ParseSelector(*expression)
+	if err != nil {
+		return err
+	}
+
+	selected, err := selector.Select(imagesJson)
+	if err != nil {
+		return err
+	}
+
+	if *builderStacks {
+		selected, err = imagesJson.WithBuilderStacks(selected)
+		if err != nil {
+			return err
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	for _, stack := range selected {
+		err = encoder.Encode(stack)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
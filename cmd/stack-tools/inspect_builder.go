@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/paketo-community/ubi-base-stack/internal/utils"
+)
+
+func runInspectBuilder(args []string) error {
+	flags := flag.NewFlagSet("inspect-builder", flag.ContinueOnError)
+	image := flags.String("image", "", "reference of the builder to inspect")
+	archive := flags.String("archive", "", "path of the OCI archive of the builder to inspect")
+	platform := flags.String("platform", "", "os/arch of the builder to inspect, the host platform when omitted")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if (*image == "") == (*archive == "") {
+		return errors.New("exactly one of --image or --archive must be provided")
+	}
+
+	p, err := utils.ParsePlatform(*platform)
+	if err != nil {
+		return err
+	}
+
+	var info utils.BuilderInfo
+	if *image != "" {
+		info, err = utils.InspectPublishedBuilder(*image, p)
+	} else {
+		info, err = utils.InspectBuilderArchive(*archive, p)
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(info)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+)
+
+func runLifecycle(args []string) error {
+	flags := flag.NewFlagSet("lifecycle", flag.ContinueOnError)
+	root := flags.String("root", ".", "path to the root of the stack repository")
+	name := flags.String("name", "", "name of the entry to check, defaults to every entry")
+	image := flags.String("image", "", "name of the build or run image whose entry to check")
+	publish := flags.Bool("publish", false, "fail for entries that are past the publish grace period")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	imagesJson, err := images.Load(*root)
+	if err != nil {
+		return err
+	}
+
+	stacks := imagesJson.StackImages
+	switch {
+	case *name != "" && *image != "":
+		return errors.New("--name and --image are mutually exclusive")
+	case *name != "":
+		stack, err := imagesJson.Lookup(*name)
+		if err != nil {
+			return err
+		}
+		stacks = []images.StackImages{stack}
+	case *image != "":
+		stack, err := imagesJson.LookupImage(*image)
+		if err != nil {
+			return err
+		}
+		stacks = []images.StackImages{stack}
+	}
+
+	now := time.Now()
+
+	var errs []error
+	for _, stack := range stacks {
+		if warning := stack.LifecycleWarning(now); warning != "" {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		}
+
+		if *publish {
+			err = stack.CheckPublishable(now)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func runMetadataLabel(args []string) error {
+	flags := flag.NewFlagSet("metadata-label", flag.ContinueOnError)
+	root := flags.String("root", ".", "path to the root of the stack repository")
+	name := flags.String("name", "", "name of the entry")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	imagesJson, err := images.Load(*root)
+	if err != nil {
+		return err
+	}
+
+	stack, err := imagesJson.Lookup(*name)
+	if err != nil {
+		return err
+	}
+
+	label, err := stack.MetadataLabel()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(label)
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/paketo-community/ubi-base-stack/internal/integration"
+)
+
+func runLock(args []string) error {
+	flags := flag.NewFlagSet("lock", flag.ContinueOnError)
+	root := flags.String("root", ".", "path to the root of the stack repository")
+	pin := flags.Bool("pin", false, "pin the unpinned sources of integration.json to the releases they resolve to")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	config, err := integration.Load(*root)
+	if err != nil {
+		return err
+	}
+
+	store := integration.NewBuildpackStore()
+	artifacts, err := integration.Resolve(*root, config, store)
+	if err != nil {
+		return err
+	}
+
+	if *pin {
+		config, err = config.Pin(artifacts)
+		if err != nil {
+			return err
+		}
+
+		err = config.Write(*root)
+		if err != nil {
+			return err
+		}
+
+		// pinned releases are downloaded from their release assets, which
+		// may differ from the artifacts of the latest releases
+		artifacts, err = integration.Resolve(*root, config, store)
+		if err != nil {
+			return err
+		}
+	}
+
+	return integration.Lock(artifacts).Write(*root)
+}
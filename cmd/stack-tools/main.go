@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+type command struct {
+	description string
+	run         func(args []string) error
+}
+
+var commands = map[string]command{
+	"base-images": {
+		description: "Checks that images.json declares the base images the Dockerfiles are built from",
+		run:         runBaseImages,
+	},
+	"builder": {
+		description: "Assembles a builder from the build image of a variant's distro without pack, into an OCI archive or a registry",
+		run:         runBuilder,
+	},
+	"edit-images": {
+		description: "Adds, updates, removes or reorders entries of stacks/images.json without reformatting it",
+		run:         runEditImages,
+	},
+	"images": {
+		description: "Prints the validated entries of stacks/images.json, optionally narrowed with --select, one JSON object per line",
+		run:         runImages,
+	},
+	"inspect-builder": {
+		description: "Prints the buildpacks, extensions, orders, run images, target and lifecycle a builder declares in its labels",
+		run:         runInspectBuilder,
+	},
+	"lifecycle": {
+		description: "Warns about deprecated and expired variants and, with --publish, fails for variants past the publish grace period",
+		run:         runLifecycle,
+	},
+	"lock": {
+		description: "Resolves the buildpacks and extensions of integration.json and records them in integration.lock.json",
+		run:         runLock,
+	},
+	"matrix": {
+		description: "Prints the variant and architecture matrix of the release workflow as JSON",
+		run:         runMatrix,
+	},
+	"materialize": {
+		description: "Resolves the parent chain and, with --name, the templated args of a stack.toml into a file jam create-stack can consume",
+		run:         runMaterialize,
+	},
+	"metadata-label": {
+		description: "Prints the io.buildpacks.stack.metadata label of the images of a variant",
+		run:         runMetadataLabel,
+	},
+	"profile": {
+		description: "Prints an environment profile of profiles.json along with the registries.json targets it publishes to",
+		run:         runProfile,
+	},
+	"publish-plan": {
+		description: "Prints the fully qualified references every image of a release is pushed to, one JSON object per line",
+		run:         runPublishPlan,
+	},
+	"reconcile": {
+		description: "Reports stack directories and images.json entries that do not match each other",
+		run:         runReconcile,
+	},
+	"schema": {
+		description: "Regenerates the JSON Schemas of the repository configuration files",
+		run:         runSchema,
+	},
+	"validate": {
+		description: "Validates the repository configuration files against their schemas",
+		run:         runValidate,
+	},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	err := cmd.run(os.Args[2:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "Usage: stack-tools <command> [OPTIONS]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "COMMANDS")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-20s %s\n", name, commands[name].description)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"flag"
+
+	"github.com/paketo-community/ubi-base-stack/internal/descriptor"
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+)
+
+func runMaterialize(args []string) error {
+	flags := flag.NewFlagSet("materialize", flag.ContinueOnError)
+	root := flags.String("root", ".", "path to the root of the stack repository")
+	config := flags.String("config", "", "path to the stack.toml to resolve")
+	output := flags.String("output", "", "path to write the resolved stack.toml to")
+	name := flags.String("name", "", "images.json entry whose fields the args are expanded against, args are left as is when omitted")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *config == "" || *output == "" {
+		return errors.New("both --config and --output must be provided")
+	}
+
+	var variables *descriptor.Variables
+	if *name != "" {
+		imagesJson, err := images.Load(*root)
+		if err != nil {
+			return err
+		}
+
+		stack, err := imagesJson.Lookup(*name)
+		if err != nil {
+			return err
+		}
+
+		v, err := descriptor.VariablesFor(imagesJson, stack)
+		if err != nil {
+			return err
+		}
+		variables = &v
+	}
+
+	return descriptor.Materialize(*config, *output, variables)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/paketo-community/ubi-base-stack/internal/matrix"
+)
+
+func runMatrix(args []string) error {
+	flags := flag.NewFlagSet("matrix", flag.ContinueOnError)
+	root := flags.String("root", ".", "path to the root of the stack repository")
+	previousRoot := flags.String("previous-root", "", "path to a checkout of the previous release, everything is new when omitted")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	imagesJson, err := images.Load(*root)
+	if err != nil {
+		return err
+	}
+
+	previous := matrix.Previous{}
+	if *previousRoot != "" {
+		previous, err = matrix.LoadPrevious(*previousRoot)
+		if err != nil {
+			return err
+		}
+	}
+
+	m, err := matrix.Build(*root, imagesJson, previous, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, name := range m.Retired {
+		fmt.Fprintf(os.Stderr, "warning: %s is retired and left out of the matrix\n", name)
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(m)
+}
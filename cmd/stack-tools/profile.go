@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+
+	"github.com/paketo-community/ubi-base-stack/internal/profiles"
+	"github.com/paketo-community/ubi-base-stack/internal/registries"
+)
+
+func runProfile(args []string) error {
+	flags := flag.NewFlagSet("profile", flag.ContinueOnError)
+	root := flags.String("root", ".", "path to the root of the stack repository")
+	name := flags.String("profile", "", "profile to print, the default profile when omitted")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	p, err := profiles.Load(*root)
+	if err != nil {
+		return err
+	}
+
+	selected, profile, err := p.Select(*name)
+	if err != nil {
+		return err
+	}
+
+	targets, err := registries.Load(*root)
+	if err != nil {
+		return err
+	}
+
+	targets, err = targets.Select(profile.PublishTargets)
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(struct {
+		Name string `json:"name"`
+		profiles.Profile
+		// Targets are the enabled registries.json targets of the publish
+		// targets, an empty list rather than null so that jq can iterate
+		// over it.
+		Targets []registries.Target `json:"targets"`
+	}{
+		Name:    selected,
+		Profile: profile,
+		Targets: targets.Targets,
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/paketo-community/ubi-base-stack/internal/profiles"
+	"github.com/paketo-community/ubi-base-stack/internal/registries"
+)
+
+func runPublishPlan(args []string) error {
+	flags := flag.NewFlagSet("publish-plan", flag.ContinueOnError)
+	root := flags.String("root", ".", "path to the root of the stack repository")
+	owner := flags.String("owner", "paketo-community", "GitHub organization the release belongs to")
+	repository := flags.String("repository", "ubi-base-stack", "GitHub repository the release belongs to")
+	version := flags.String("version", "", "version of the release")
+	profile := flags.String("profile", "", "profile whose publish_targets are planned, the default profile when omitted")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *version == "" {
+		return fmt.Errorf("--version is required")
+	}
+
+	imagesJson, err := images.Load(*root)
+	if err != nil {
+		return err
+	}
+
+	targets, err := registries.Load(*root)
+	if err != nil {
+		return err
+	}
+
+	p, err := profiles.Load(*root)
+	if err != nil {
+		return err
+	}
+
+	name, selected, err := p.Select(*profile)
+	if err != nil {
+		return err
+	}
+
+	targets, err = targets.Select(selected.PublishTargets)
+	if err != nil {
+		return err
+	}
+
+	if len(targets.Targets) == 0 {
+		fmt.Fprintf(os.Stderr, "note: profile %s publishes to no enabled target, every image is planned without refs\n", name)
+	}
+
+	// variants past the publish grace period are left out of the plan
+	now := time.Now()
+	for _, stack := range imagesJson.StackImages {
+		err = stack.CheckPublishable(now)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+		}
+	}
+
+	plan, err := targets.Plan(imagesJson, registries.Release{
+		Owner:      *owner,
+		Repository: *repository,
+		Version:    *version,
+	}, now)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	for _, publication := range plan {
+		err = encoder.Encode(publication)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"path/filepath"
+
+	"github.com/paketo-community/ubi-base-stack/internal/descriptor"
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+)
+
+func runReconcile(args []string) error {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	root := flags.String("root", ".", "path to the root of the stack repository")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	// dangling config_dir entries fail images.Load, so only parse the file and
+	// let the reconciliation report them
+	imagesJson, err := images.LoadFile(filepath.Join(*root, images.Filename))
+	if err != nil {
+		return err
+	}
+
+	return descriptor.Reconcile(*root, imagesJson)
+}
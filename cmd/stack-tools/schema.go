@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/paketo-community/ubi-base-stack/internal/schema"
+)
+
+func runSchema(args []string) error {
+	flags := flag.NewFlagSet("schema", flag.ContinueOnError)
+	root := flags.String("root", ".", "path to the root of the stack repository")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Join(*root, schema.Dir), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	for _, document := range schema.Documents() {
+		content, err := marshalSchema(document.Schema)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(filepath.Join(*root, schema.Dir, document.SchemaFile), content, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func marshalSchema(s *schema.Schema) ([]byte, error) {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(content, '\n'), nil
+}
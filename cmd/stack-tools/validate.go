@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/paketo-community/ubi-base-stack/internal/descriptor"
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/paketo-community/ubi-base-stack/internal/integration"
+	"github.com/paketo-community/ubi-base-stack/internal/matrix"
+	"github.com/paketo-community/ubi-base-stack/internal/profiles"
+	"github.com/paketo-community/ubi-base-stack/internal/registries"
+	"github.com/paketo-community/ubi-base-stack/internal/schema"
+)
+
+func runValidate(args []string) error {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	root := flags.String("root", ".", "path to the root of the stack repository")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, document := range schema.Documents() {
+		content, err := os.ReadFile(filepath.Join(*root, document.Path))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, violation := range schema.Validate(document.Schema, content) {
+			errs = append(errs, fmt.Errorf("%s:%w", document.Path, violation))
+		}
+
+		expected, err := marshalSchema(document.Schema)
+		if err != nil {
+			return err
+		}
+
+		published, err := os.ReadFile(filepath.Join(*root, schema.Dir, document.SchemaFile))
+		if err != nil || !bytes.Equal(published, expected) {
+			errs = append(errs, fmt.Errorf("%s/%s is out of date, run `stack-tools schema` to regenerate it", schema.Dir, document.SchemaFile))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	imagesJson, err := images.Load(*root)
+	if err != nil {
+		return err
+	}
+
+	targets, err := registries.Load(*root)
+	if err != nil {
+		return err
+	}
+
+	_, err = targets.Plan(imagesJson, registries.Release{Owner: "owner", Repository: "repository", Version: "0.0.0"}, time.Now())
+	if err != nil {
+		return err
+	}
+
+	config, err := integration.Load(*root)
+	if err != nil {
+		return err
+	}
+
+	lock, err := integration.LoadLock(*root)
+	if err != nil && !errors.Is(err, integration.ErrNoLock) {
+		return err
+	}
+
+	if lock != nil {
+		err = lock.Check(config)
+		if err != nil {
+			return err
+		}
+	}
+
+	p, err := profiles.Load(*root)
+	if err != nil {
+		return err
+	}
+
+	err = p.CheckReferences(targets, config)
+	if err != nil {
+		return err
+	}
+
+	err = descriptor.Validate(*root, imagesJson)
+	if err != nil {
+		return err
+	}
+
+	_, err = matrix.Build(*root, imagesJson, matrix.Previous{}, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return descriptor.CheckBaseImages(*root, imagesJson)
+}
@@ -1,7 +1,9 @@
 package acceptance_test
 
 import (
-	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,6 +11,11 @@ import (
 	"time"
 
 	"github.com/paketo-buildpacks/occam"
+	"github.com/paketo-community/ubi-base-stack/internal/compat"
+	"github.com/paketo-community/ubi-base-stack/internal/descriptor"
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/paketo-community/ubi-base-stack/internal/integration"
+	"github.com/paketo-community/ubi-base-stack/internal/profiles"
 	utils "github.com/paketo-community/ubi-base-stack/internal/utils"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
@@ -16,35 +23,31 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+var profileFlag = flag.String("profile", "", "profile of profiles.json to test against, defaults to $"+profiles.EnvVar+" or the default profile")
+var lifecyclesFlag = flag.String("lifecycles", "", "comma separated lifecycle archives to run the integration suites against, defaults to $"+profiles.LifecyclesEnvVar+" or the lifecycles of the profile")
+var lifecycleReportFlag = flag.String("lifecycle-report", "lifecycle-report.json", "path of the lifecycle compatibility report written when testing against lifecycle archives")
+
 var root string
 var RegistryUrl string
-var DefaultStack StackImages
-
-type StackImages struct {
-	Name                    string `json:"name"`
-	ConfigDir               string `json:"config_dir"`
-	OutputDir               string `json:"output_dir"`
-	BuildImage              string `json:"build_image"`
-	RunImage                string `json:"run_image"`
-	BuildReceiptFilename    string `json:"build_receipt_filename"`
-	RunReceiptFilename      string `json:"run_receipt_filename"`
-	CreateBuildImage        bool   `json:"create_build_image,omitempty"`
-	BaseBuildContainerImage string `json:"base_build_container_image,omitempty"`
-	BaseRunContainerImage   string `json:"base_run_container_image"`
-	Type                    string `json:"type,omitempty"`
-}
 
-type ImagesJson struct {
-	SupportUsns       bool          `json:"support_usns"`
-	UpdateOnNewImage  bool          `json:"update_on_new_image"`
-	ReceiptsShowLimit int           `json:"receipts_show_limit"`
-	StackImages       []StackImages `json:"images"`
-}
+// BuildStacks and DefaultRunStacks are keyed by distro.
+var BuildStacks map[string]images.StackImages
+var DefaultRunStacks map[string]images.StackImages
 
-var builder struct {
+type builderImages struct {
 	imageUrl      string
 	buildImageUrl string
-	runImageUrl   string
+	// runImageUrls are keyed by variant name.
+	runImageUrls map[string]string
+}
+
+// lifecycleBuilders pairs the build image of every tested distro with the run
+// images of its tested variants, keyed by distro, in builders created with
+// one lifecycle.
+type lifecycleBuilders struct {
+	// lifecycle is the zero value for the lifecycle pack bundles.
+	lifecycle compat.Lifecycle
+	builders  map[string]builderImages
 }
 
 var settings struct {
@@ -66,14 +69,14 @@ var settings struct {
 		}
 	}
 
-	Config struct {
-		BuildPlan          string `json:"build-plan"`
-		UbiNodejsExtension string `json:"ubi-nodejs-extension"`
-		Nodejs             string `json:"nodejs"`
-		GoDist             string `json:"go-dist"`
-	}
+	Config integration.Config
+
+	// Profile is the name of the selected profile of profiles.json.
+	Profile    string
+	PullPolicy string
 
-	ImagesJson ImagesJson
+	ImagesJson images.ImagesJson
+	Stacks     map[string]descriptor.Stack
 }
 
 func by(_ string, f func()) { f() }
@@ -85,93 +88,257 @@ func TestAcceptance(t *testing.T) {
 
 	docker := occam.NewDocker()
 
-	RegistryUrl = os.Getenv("REGISTRY_URL")
-	Expect(RegistryUrl).NotTo(Equal(""))
-
 	root, err = filepath.Abs(".")
 	Expect(err).ToNot(HaveOccurred())
 
-	integration_json, err := os.Open("./integration.json")
+	allProfiles, err := profiles.Load(root)
+	Expect(err).NotTo(HaveOccurred())
+
+	profileName := *profileFlag
+	if profileName == "" {
+		profileName = os.Getenv(profiles.EnvVar)
+	}
+
+	var profile profiles.Profile
+	settings.Profile, profile, err = allProfiles.Select(profileName)
+	Expect(err).NotTo(HaveOccurred())
+	settings.PullPolicy = profile.PullPolicy
+
+	RegistryUrl, err = profile.Registry()
+	Expect(err).NotTo(HaveOccurred())
+
+	settings.Config, err = integration.Load(root)
 	Expect(err).NotTo(HaveOccurred())
 
-	Expect(json.NewDecoder(integration_json).Decode(&settings.Config)).To(Succeed())
-	Expect(integration_json.Close()).To(Succeed())
+	settings.Config, err = settings.Config.Override(profile.Sources)
+	Expect(err).NotTo(HaveOccurred())
 
-	images_json, err := os.Open("./stacks/images.json")
+	settings.ImagesJson, err = images.Load(root)
 	Expect(err).NotTo(HaveOccurred())
 
-	Expect(json.NewDecoder(images_json).Decode(&settings.ImagesJson)).To(Succeed())
-	Expect(images_json.Close()).To(Succeed())
+	Expect(descriptor.Validate(root, settings.ImagesJson)).To(Succeed())
 
-	testOnlyStacksEnv := os.Getenv("TEST_ONLY_STACKS")
-	var testOnlystacks []string
+	settings.Stacks, err = descriptor.LoadAll(root, settings.ImagesJson)
+	Expect(err).NotTo(HaveOccurred())
 
-	if testOnlyStacksEnv != "" {
-		testOnlystacks = strings.Split(testOnlyStacksEnv, " ")
+	// The build image provider and the default run image are resolved before
+	// filtering so that any selection of stacks can still be paired with them.
+	BuildStacks = map[string]images.StackImages{}
+	DefaultRunStacks = map[string]images.StackImages{}
+	for _, distro := range settings.ImagesJson.DistroNames() {
+		BuildStacks[distro], err = settings.ImagesJson.BuildStack(distro)
+		Expect(err).NotTo(HaveOccurred())
+
+		DefaultRunStacks[distro], err = settings.ImagesJson.DefaultRunStack(distro)
+		Expect(err).NotTo(HaveOccurred())
 	}
 
-	if len(testOnlystacks) > 0 {
-		var filteredStacks []StackImages
-		for _, stack := range settings.ImagesJson.StackImages {
-			for _, testStack := range testOnlystacks {
-				if stack.Name == testStack {
-					filteredStacks = append(filteredStacks, stack)
-				}
-			}
+	// TEST_ONLY_STACKS holds a selector, see images.ParseSelector
+	selector, err := images.ParseSelector(os.Getenv("TEST_ONLY_STACKS"))
+	Expect(err).NotTo(HaveOccurred())
+
+	settings.ImagesJson.StackImages, err = selector.Select(settings.ImagesJson)
+	Expect(err).NotTo(HaveOccurred())
+
+	// a checkout without a lock file runs against whatever the sources
+	// resolve to until `stack-tools lock` records them
+	lock, err := integration.LoadLock(root)
+	if errors.Is(err, integration.ErrNoLock) {
+		fmt.Fprintf(os.Stderr, "warning: %s, the buildpacks and extensions are not verified\n", err)
+	} else {
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	artifacts, err := integration.Resolve(root, settings.Config, integration.NewBuildpackStore())
+	Expect(err).NotTo(HaveOccurred())
+
+	// sources the profile overrides are not pinned by the lock file
+	locked := map[string]integration.Artifact{}
+	for name, artifact := range artifacts {
+		if _, ok := profile.Sources[name]; ok {
+			delete(lock, name)
+			continue
 		}
-		settings.ImagesJson.StackImages = filteredStacks
+		locked[name] = artifact
+	}
+	if lock != nil {
+		Expect(lock.Verify(locked)).To(Succeed())
 	}
 
-	DefaultStack = getDefaultStack(settings.ImagesJson.StackImages)
-	Expect(DefaultStack).NotTo(Equal(StackImages{}))
+	settings.Extensions.UbiNodejsExtension.Online = artifacts["ubi-nodejs-extension"].Path
+	settings.Buildpacks.Nodejs.Online = artifacts["nodejs"].Path
+	settings.Buildpacks.BuildPlan.Online = artifacts["build-plan"].Path
+	settings.Buildpacks.GoDist.Online = artifacts["go-dist"].Path
 
-	buildpackStore := occam.NewBuildpackStore()
+	host, err := utils.ParsePlatform("")
+	Expect(err).NotTo(HaveOccurred())
 
-	settings.Extensions.UbiNodejsExtension.Online, err = buildpackStore.Get.
-		Execute(settings.Config.UbiNodejsExtension)
-	Expect(err).ToNot(HaveOccurred())
+	// builders with the same inputs are only created once, and the build and
+	// run images are only pushed once for all lifecycles
+	cache := utils.NewBuilderCache()
+
+	// one builder per distro ships the run images of all its tested
+	// variants, the default run image first
+	createBuilders := func(lifecycle *compat.Lifecycle) map[string]builderImages {
+		builders := map[string]builderImages{}
+		for _, distro := range settings.ImagesJson.DistroNames() {
+			runStacks := []images.StackImages{DefaultRunStacks[distro]}
+			for _, stack := range settings.ImagesJson.StackImages {
+				if stack.Distro == distro && stack.Name != DefaultRunStacks[distro].Name {
+					runStacks = append(runStacks, stack)
+				}
+			}
 
-	settings.Buildpacks.Nodejs.Online, err = buildpackStore.Get.
-		Execute(settings.Config.Nodejs)
-	Expect(err).ToNot(HaveOccurred())
+			options, err := utils.StackBuilderOptions(
+				root,
+				settings.ImagesJson.Distros[distro],
+				BuildStacks[distro],
+				runStacks,
+				utils.BuilderOptions{
+					RegistryURL: RegistryUrl,
+					Description: fmt.Sprintf("%s acceptance test builder", distro),
+					Lifecycle:   utils.BuilderLifecycle{URI: lifecycle.Archive},
+					// the extension switches Node.js apps to the run image
+					// of their variant
+					Extensions: []utils.BuilderModule{{URI: settings.Extensions.UbiNodejsExtension.Online}},
+					OrderExtensions: []utils.OrderGroup{
+						{{ID: "paketo-community/ubi-nodejs-extension", Optional: true}},
+					},
+				},
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			generated, err := cache.Generate(options)
+			Expect(err).NotTo(HaveOccurred())
+
+			builder := builderImages{
+				imageUrl:      generated.Builder,
+				buildImageUrl: generated.BuildImage,
+				runImageUrls:  map[string]string{},
+			}
+			for index, stack := range runStacks {
+				builder.runImageUrls[stack.Name] = generated.RunImages[index]
+			}
+			builders[distro] = builder
 
-	settings.Buildpacks.BuildPlan.Online, err = buildpackStore.Get.
-		Execute(settings.Config.BuildPlan)
-	Expect(err).ToNot(HaveOccurred())
+			if lifecycle.Archive != "" && lifecycle.Version == "" {
+				info, err := utils.InspectPublishedBuilder(builder.imageUrl, host)
+				Expect(err).NotTo(HaveOccurred())
 
-	settings.Buildpacks.GoDist.Online, err = buildpackStore.Get.
-		Execute(settings.Config.GoDist)
-	Expect(err).NotTo(HaveOccurred())
+				lifecycle.Version = info.Lifecycle.Version
+				lifecycle.PlatformAPIs = info.SupportedPlatformAPIs()
+				lifecycle.BuildpackAPIs = info.SupportedBuildpackAPIs()
+			}
+		}
 
-	builder.buildImageUrl, builder.runImageUrl, builder.imageUrl, err = utils.GenerateBuilder(
-		filepath.Join(root, DefaultStack.OutputDir, "build.oci"),
-		filepath.Join(root, DefaultStack.OutputDir, "run.oci"),
-		RegistryUrl,
-	)
-	Expect(err).NotTo(HaveOccurred())
+		return builders
+	}
+
+	archives := profile.LifecycleArchives(root)
+	lifecyclesValue := *lifecyclesFlag
+	if lifecyclesValue == "" {
+		lifecyclesValue = os.Getenv(profiles.LifecyclesEnvVar)
+	}
+	if lifecyclesValue != "" {
+		archives = profiles.Profile{Lifecycles: strings.Split(lifecyclesValue, ",")}.LifecycleArchives(root)
+	}
+
+	// the suites run once per lifecycle archive, or once against the
+	// lifecycle pack bundles
+	runs := []lifecycleBuilders{{}}
+	if len(archives) > 0 {
+		runs = nil
+		for _, archive := range archives {
+			runs = append(runs, lifecycleBuilders{lifecycle: compat.Lifecycle{Archive: archive}})
+		}
+	}
+
+	// archives are told apart by their path, as several of them may hold
+	// the same lifecycle version
+	var lifecycles []compat.Lifecycle
+	versions := map[string]int{}
+	for i := range runs {
+		runs[i].builders = createBuilders(&runs[i].lifecycle)
+
+		if lifecycle := runs[i].lifecycle; lifecycle.Archive != "" {
+			versions[lifecycle.Version]++
+			lifecycles = append(lifecycles, lifecycle)
+		}
+	}
+
+	var recorder *compat.Recorder
+	if len(lifecycles) > 0 {
+		recorder = compat.NewRecorder(lifecycles)
+	}
 
 	SetDefaultEventuallyTimeout(120 * time.Second)
 
 	suite := spec.New("Acceptance", spec.Report(report.Terminal{}), spec.Parallel())
 	suite("Metadata", testMetadata)
-	suite("NodejsStackIntegration", testNodejsStackIntegration)
-	suite("buildpackIntegration", testBuildpackIntegration)
-	suite.Run(t)
+	for _, stack := range settings.ImagesJson.StackImages {
+		stack := stack
 
-	/** Cleanup **/
-	lifecycleImageID, err := utils.GetLifecycleImageID(docker, builder.imageUrl)
-	Expect(err).NotTo(HaveOccurred())
+		suites, ok := variantSuites[stack.Type]
+		Expect(ok).To(BeTrue(), fmt.Sprintf("no integration suites are registered for type %q of %s", stack.Type, stack.Name))
 
-	err = utils.RemoveImages(docker, []string{lifecycleImageID, builder.runImageUrl, builder.imageUrl})
-	Expect(err).NotTo(HaveOccurred())
+		for _, run := range runs {
+			run := run
 
-}
+			for _, variantSuite := range suites {
+				variantSuite := variantSuite
+
+				name := fmt.Sprintf("%s/%s", variantSuite.name, stack.Name)
+				if run.lifecycle.Version != "" {
+					name = fmt.Sprintf("%s/lifecycle-%s", name, run.lifecycle.Version)
+					if versions[run.lifecycle.Version] > 1 {
+						name = fmt.Sprintf("%s-%s", name, filepath.Base(run.lifecycle.Archive))
+					}
+				}
 
-func getDefaultStack(stackImages []StackImages) StackImages {
-	for _, stack := range stackImages {
-		if stack.Name == "default" {
-			return stack
+				suite(name, func(t *testing.T, context spec.G, it spec.S) {
+					if recorder != nil {
+						it.After(func() {
+							recorder.Record(stack.Name, run.lifecycle.Archive, t.Failed())
+						})
+					}
+
+					variantSuite.run(t, context, it, stack, run.builders[stack.Distro])
+				})
+			}
 		}
 	}
-	return StackImages{}
+	suite.Run(t)
+
+	if recorder != nil {
+		report := recorder.Report()
+		fmt.Print(report.Markdown())
+		Expect(report.Write(*lifecycleReportFlag)).To(Succeed())
+	}
+
+	/** Cleanup **/
+	lifecycleImageIDs := map[string]bool{}
+	err = cache.GC(func(builder utils.GeneratedBuilder) error {
+		lifecycleImageID, err := utils.GetLifecycleImageID(builder.Builder)
+		if err != nil {
+			return err
+		}
+
+		imageIDs := []string{builder.Builder}
+
+		// pack only pulls the lifecycle image for untrusted builders, which
+		// the integration suites do not use
+		if !lifecycleImageIDs[lifecycleImageID] {
+			lifecycleImageIDs[lifecycleImageID] = true
+			if _, err := docker.Image.Inspect.Execute(lifecycleImageID); err == nil {
+				imageIDs = append(imageIDs, lifecycleImageID)
+			}
+		}
+		for _, runImageUrl := range builder.RunImages {
+			imageIDs = append(imageIDs, runImageUrl)
+		}
+
+		return utils.RemoveImages(docker, imageIDs)
+	})
+	Expect(err).NotTo(HaveOccurred())
+
 }
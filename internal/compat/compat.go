@@ -0,0 +1,211 @@
+package compat
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Lifecycle is a lifecycle the integration suites ran against.
+type Lifecycle struct {
+	Version string `json:"version"`
+	// Archive is the lifecycle archive the builders were created with.
+	Archive       string   `json:"archive"`
+	PlatformAPIs  []string `json:"platform_apis"`
+	BuildpackAPIs []string `json:"buildpack_apis"`
+}
+
+// Report is the outcome of the integration suites of every variant against
+// every lifecycle.
+type Report struct {
+	Lifecycles []Lifecycle `json:"lifecycles"`
+	Variants   []Variant   `json:"variants"`
+}
+
+// Variant is the outcome of the integration suites of one variant.
+type Variant struct {
+	Name string `json:"name"`
+	// Results are in the order of the lifecycles of the report.
+	Results []Result `json:"results"`
+	// PlatformAPIs are the platform APIs of the lifecycles the variant is
+	// compatible with, oldest first.
+	PlatformAPIs []string `json:"platform_apis"`
+}
+
+// Result counts the specs of a variant that passed and failed against a
+// lifecycle.
+type Result struct {
+	Lifecycle string `json:"lifecycle"`
+	// Archive tells apart lifecycle archives that report the same version.
+	Archive string `json:"archive"`
+	Passed  int    `json:"passed"`
+	Failed  int    `json:"failed"`
+}
+
+// Compatible is true when specs ran and none of them failed.
+func (r Result) Compatible() bool {
+	return r.Passed > 0 && r.Failed == 0
+}
+
+// Recorder collects the outcome of specs, which may run in parallel. Results
+// are keyed by variant and lifecycle archive.
+type Recorder struct {
+	mutex      sync.Mutex
+	lifecycles []Lifecycle
+	results    map[string]map[string]*Result
+}
+
+// NewRecorder records the outcome of specs against lifecycles.
+func NewRecorder(lifecycles []Lifecycle) *Recorder {
+	return &Recorder{
+		lifecycles: lifecycles,
+		results:    map[string]map[string]*Result{},
+	}
+}
+
+// Record counts a spec of variant that ran against the lifecycle archive.
+func (r *Recorder) Record(variant, archive string, failed bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.results[variant] == nil {
+		r.results[variant] = map[string]*Result{}
+	}
+
+	result := r.results[variant][archive]
+	if result == nil {
+		result = &Result{Archive: archive}
+		r.results[variant][archive] = result
+	}
+
+	if failed {
+		result.Failed++
+	} else {
+		result.Passed++
+	}
+}
+
+// Report returns the recorded outcomes, sorted by variant name.
+func (r *Recorder) Report() Report {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	report := Report{Lifecycles: r.lifecycles}
+
+	var names []string
+	for name := range r.results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		variant := Variant{Name: name}
+		apis := map[string]bool{}
+		for _, lifecycle := range r.lifecycles {
+			result := Result{Lifecycle: lifecycle.Version, Archive: lifecycle.Archive}
+			if recorded := r.results[name][lifecycle.Archive]; recorded != nil {
+				result.Passed = recorded.Passed
+				result.Failed = recorded.Failed
+			}
+			variant.Results = append(variant.Results, result)
+
+			if result.Compatible() {
+				for _, api := range lifecycle.PlatformAPIs {
+					apis[api] = true
+				}
+			}
+		}
+
+		for api := range apis {
+			variant.PlatformAPIs = append(variant.PlatformAPIs, api)
+		}
+		sort.Slice(variant.PlatformAPIs, func(i, j int) bool {
+			return compareAPIs(variant.PlatformAPIs[i], variant.PlatformAPIs[j]) < 0
+		})
+
+		report.Variants = append(report.Variants, variant)
+	}
+
+	return report
+}
+
+// Write writes the report as JSON to path.
+func (r Report) Write(path string) error {
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// Markdown renders the report as a table with a row per variant and a
+// column per lifecycle.
+func (r Report) Markdown() string {
+	var builder strings.Builder
+
+	header := []string{"Variant"}
+	separator := []string{"---"}
+	versions := map[string]int{}
+	for _, lifecycle := range r.Lifecycles {
+		versions[lifecycle.Version]++
+	}
+
+	for _, lifecycle := range r.Lifecycles {
+		column := fmt.Sprintf("lifecycle %s", lifecycle.Version)
+		if versions[lifecycle.Version] > 1 {
+			column = fmt.Sprintf("%s (%s)", column, filepath.Base(lifecycle.Archive))
+		}
+		header = append(header, column)
+		separator = append(separator, "---")
+	}
+	header = append(header, "Platform APIs")
+	separator = append(separator, "---")
+
+	fmt.Fprintf(&builder, "| %s |\n", strings.Join(header, " | "))
+	fmt.Fprintf(&builder, "| %s |\n", strings.Join(separator, " | "))
+
+	for _, variant := range r.Variants {
+		row := []string{variant.Name}
+		for _, result := range variant.Results {
+			switch {
+			case result.Compatible():
+				row = append(row, fmt.Sprintf("pass (%d)", result.Passed))
+			case result.Failed > 0:
+				row = append(row, fmt.Sprintf("fail (%d/%d)", result.Failed, result.Passed+result.Failed))
+			default:
+				row = append(row, "not run")
+			}
+		}
+		row = append(row, strings.Join(variant.PlatformAPIs, ", "))
+
+		fmt.Fprintf(&builder, "| %s |\n", strings.Join(row, " | "))
+	}
+
+	return builder.String()
+}
+
+// compareAPIs orders API versions such as 0.9 and 0.10 numerically.
+func compareAPIs(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, errX := strconv.Atoi(as[i])
+		y, errY := strconv.Atoi(bs[i])
+		if errX != nil || errY != nil {
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+			continue
+		}
+		if x != y {
+			return x - y
+		}
+	}
+
+	return len(as) - len(bs)
+}
@@ -0,0 +1,131 @@
+package compat_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/paketo-community/ubi-base-stack/internal/compat"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testCompat(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		recorder *compat.Recorder
+	)
+
+	it.Before(func() {
+		recorder = compat.NewRecorder([]compat.Lifecycle{
+			{Version: "0.17.2", Archive: "lifecycles/lifecycle-v0.17.2.tgz", PlatformAPIs: []string{"0.7", "0.12"}, BuildpackAPIs: []string{"0.7", "0.10"}},
+			{Version: "0.20.1", Archive: "lifecycles/lifecycle-v0.20.1.tgz", PlatformAPIs: []string{"0.9", "0.13"}, BuildpackAPIs: []string{"0.7", "0.11"}},
+		})
+	})
+
+	context("Report", func() {
+		it("reports every variant against every lifecycle", func() {
+			recorder.Record("nodejs-20", "lifecycles/lifecycle-v0.17.2.tgz", false)
+			recorder.Record("nodejs-20", "lifecycles/lifecycle-v0.17.2.tgz", false)
+			recorder.Record("nodejs-20", "lifecycles/lifecycle-v0.20.1.tgz", true)
+			recorder.Record("nodejs-20", "lifecycles/lifecycle-v0.20.1.tgz", false)
+			recorder.Record("default", "lifecycles/lifecycle-v0.17.2.tgz", false)
+
+			report := recorder.Report()
+			Expect(report.Lifecycles).To(HaveLen(2))
+			Expect(report.Variants).To(Equal([]compat.Variant{
+				{
+					Name: "default",
+					Results: []compat.Result{
+						{Lifecycle: "0.17.2", Archive: "lifecycles/lifecycle-v0.17.2.tgz", Passed: 1},
+						{Lifecycle: "0.20.1", Archive: "lifecycles/lifecycle-v0.20.1.tgz"},
+					},
+					PlatformAPIs: []string{"0.7", "0.12"},
+				},
+				{
+					Name: "nodejs-20",
+					Results: []compat.Result{
+						{Lifecycle: "0.17.2", Archive: "lifecycles/lifecycle-v0.17.2.tgz", Passed: 2},
+						{Lifecycle: "0.20.1", Archive: "lifecycles/lifecycle-v0.20.1.tgz", Passed: 1, Failed: 1},
+					},
+					PlatformAPIs: []string{"0.7", "0.12"},
+				},
+			}))
+		})
+
+		it("keeps archives of the same version apart", func() {
+			recorder = compat.NewRecorder([]compat.Lifecycle{
+				{Version: "0.20.1", Archive: "lifecycles/lifecycle-v0.20.1.tgz", PlatformAPIs: []string{"0.13"}},
+				{Version: "0.20.1", Archive: "lifecycles/lifecycle-patched.tgz", PlatformAPIs: []string{"0.13"}},
+			})
+			recorder.Record("default", "lifecycles/lifecycle-v0.20.1.tgz", false)
+			recorder.Record("default", "lifecycles/lifecycle-patched.tgz", true)
+
+			report := recorder.Report()
+			Expect(report.Variants[0].Results).To(Equal([]compat.Result{
+				{Lifecycle: "0.20.1", Archive: "lifecycles/lifecycle-v0.20.1.tgz", Passed: 1},
+				{Lifecycle: "0.20.1", Archive: "lifecycles/lifecycle-patched.tgz", Failed: 1},
+			}))
+			Expect(report.Markdown()).To(HavePrefix("| Variant | lifecycle 0.20.1 (lifecycle-v0.20.1.tgz) | lifecycle 0.20.1 (lifecycle-patched.tgz) | Platform APIs |\n"))
+		})
+
+		it("orders the platform APIs numerically", func() {
+			recorder.Record("default", "lifecycles/lifecycle-v0.17.2.tgz", false)
+			recorder.Record("default", "lifecycles/lifecycle-v0.20.1.tgz", false)
+
+			Expect(recorder.Report().Variants[0].PlatformAPIs).To(Equal([]string{"0.7", "0.9", "0.12", "0.13"}))
+		})
+
+		it("records specs that run in parallel", func() {
+			var group sync.WaitGroup
+			for i := 0; i < 50; i++ {
+				group.Add(1)
+				go func() {
+					defer group.Done()
+					recorder.Record("default", "lifecycles/lifecycle-v0.17.2.tgz", false)
+				}()
+			}
+			group.Wait()
+
+			Expect(recorder.Report().Variants[0].Results[0].Passed).To(Equal(50))
+		})
+	})
+
+	context("Markdown", func() {
+		it("renders a row per variant", func() {
+			recorder.Record("default", "lifecycles/lifecycle-v0.17.2.tgz", false)
+			recorder.Record("default", "lifecycles/lifecycle-v0.20.1.tgz", true)
+			recorder.Record("nodejs-20", "lifecycles/lifecycle-v0.20.1.tgz", false)
+
+			Expect(recorder.Report().Markdown()).To(Equal(`| Variant | lifecycle 0.17.2 | lifecycle 0.20.1 | Platform APIs |
+| --- | --- | --- | --- |
+| default | pass (1) | fail (1/1) | 0.7, 0.12 |
+| nodejs-20 | not run | pass (1) | 0.9, 0.13 |
+`))
+		})
+	})
+
+	context("Write", func() {
+		it("writes the report as JSON", func() {
+			dir, err := os.MkdirTemp("", "compat")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			recorder.Record("default", "lifecycles/lifecycle-v0.17.2.tgz", false)
+
+			path := filepath.Join(dir, "lifecycle-report.json")
+			Expect(recorder.Report().Write(path)).To(Succeed())
+
+			content, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			var report compat.Report
+			Expect(json.Unmarshal(content, &report)).To(Succeed())
+			Expect(report).To(Equal(recorder.Report()))
+		})
+	})
+}
@@ -0,0 +1,14 @@
+package compat_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitCompat(t *testing.T) {
+	suite := spec.New("compat", spec.Report(report.Terminal{}))
+	suite("Compat", testCompat)
+	suite.Run(t)
+}
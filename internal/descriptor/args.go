@@ -0,0 +1,186 @@
+package descriptor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+)
+
+// Variables are the fields of a variant the values of [build.args] and
+// [run.args] can reference as text/template actions, e.g.
+// NODEJS_VERSION = "{{.RuntimeVersion}}".
+type Variables struct {
+	Name           string
+	Type           string
+	RuntimeVersion string
+	Distro         string
+	// OSVersion is the major version of the distro, e.g. 8.
+	OSVersion      string
+	BaseBuildImage ImageReference
+	BaseRunImage   ImageReference
+}
+
+// ImageReference is a base image of images.json without its docker://
+// prefix. It prints as the full reference, and {{.BaseRunImage.Digest}} and
+// friends pick its parts.
+type ImageReference string
+
+// Repository returns the reference without its tag or digest.
+func (r ImageReference) Repository() (string, error) {
+	reference, err := r.parse()
+	if err != nil {
+		return "", err
+	}
+
+	return reference.Context().Name(), nil
+}
+
+// Tag returns the tag of the reference, latest when it has none.
+func (r ImageReference) Tag() (string, error) {
+	reference, err := r.parse()
+	if err != nil {
+		return "", err
+	}
+
+	tag, ok := reference.(name.Tag)
+	if !ok {
+		return "", fmt.Errorf("base image %q is pinned to a digest, not a tag", string(r))
+	}
+
+	return tag.TagStr(), nil
+}
+
+// Digest returns the digest the reference is pinned to.
+func (r ImageReference) Digest() (string, error) {
+	reference, err := r.parse()
+	if err != nil {
+		return "", err
+	}
+
+	digest, ok := reference.(name.Digest)
+	if !ok {
+		return "", fmt.Errorf("base image %q is not pinned to a digest", string(r))
+	}
+
+	return digest.DigestStr(), nil
+}
+
+func (r ImageReference) parse() (name.Reference, error) {
+	if r == "" {
+		return nil, errors.New("no base image is declared")
+	}
+
+	return name.ParseReference(string(r))
+}
+
+// VariablesFor returns the variables of an images.json entry.
+func VariablesFor(imagesJson images.ImagesJson, image images.StackImages) (Variables, error) {
+	distro, err := imagesJson.Distro(image)
+	if err != nil {
+		return Variables{}, err
+	}
+
+	return Variables{
+		Name:           image.Name,
+		Type:           image.Type,
+		RuntimeVersion: image.RuntimeVersion,
+		Distro:         image.Distro,
+		OSVersion:      distro.OSVersion,
+		BaseBuildImage: ImageReference(strings.TrimPrefix(image.BaseBuildContainerImage, DockerTransport)),
+		BaseRunImage:   ImageReference(strings.TrimPrefix(image.BaseRunContainerImage, DockerTransport)),
+	}, nil
+}
+
+// ExpandArgs evaluates the [build.args] and [run.args] of stack against
+// variables. The maps of stack are left untouched.
+func ExpandArgs(stack Stack, variables Variables) (Stack, error) {
+	var errs []error
+
+	sections := []struct {
+		name  string
+		image *Image
+	}{
+		{name: "build", image: &stack.Build},
+		{name: "run", image: &stack.Run},
+	}
+
+	for _, section := range sections {
+		if len(section.image.Args) == 0 {
+			continue
+		}
+
+		var keys []string
+		for key := range section.image.Args {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		args := map[string]string{}
+		for _, key := range keys {
+			value, err := expand(section.image.Args[key], variables)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s.args.%s: %w", variables.Name, section.name, key, err))
+				continue
+			}
+			args[key] = value
+		}
+		section.image.Args = args
+	}
+
+	if len(errs) > 0 {
+		return Stack{}, errors.Join(errs...)
+	}
+
+	return stack, nil
+}
+
+// LoadExpanded parses the stack.toml of every entry in images.json with its
+// args expanded, keyed by entry name.
+func LoadExpanded(root string, imagesJson images.ImagesJson) (map[string]Stack, error) {
+	stacks, err := LoadAll(root, imagesJson)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, image := range imagesJson.StackImages {
+		variables, err := VariablesFor(imagesJson, image)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		stacks[image.Name], err = ExpandArgs(stacks[image.Name], variables)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Join(image.ConfigDir, Filename), err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return stacks, nil
+}
+
+func expand(value string, variables Variables) (string, error) {
+	tmpl, err := template.New("arg").Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	err = tmpl.Execute(buffer, variables)
+	if err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
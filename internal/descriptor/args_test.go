@@ -0,0 +1,105 @@
+package descriptor_test
+
+import (
+	"testing"
+
+	"github.com/paketo-community/ubi-base-stack/internal/descriptor"
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testArgs(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		imagesJson images.ImagesJson
+	)
+
+	it.Before(func() {
+		imagesJson = images.ImagesJson{
+			Distros: map[string]images.Distro{
+				"ubi8": {StackID: "io.buildpacks.stacks.ubi8", OSVersion: "8"},
+			},
+			StackImages: []images.StackImages{
+				{
+					Name:                  "nodejs-20",
+					Type:                  images.TypeNodejs,
+					RuntimeVersion:        "20",
+					Distro:                "ubi8",
+					BaseRunContainerImage: "docker://registry.access.redhat.com/ubi8/nodejs-20-minimal@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+				},
+			},
+		}
+	})
+
+	context("VariablesFor", func() {
+		it("collects the fields of the entry and its distro", func() {
+			variables, err := descriptor.VariablesFor(imagesJson, imagesJson.StackImages[0])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(variables).To(Equal(descriptor.Variables{
+				Name:           "nodejs-20",
+				Type:           "nodejs",
+				RuntimeVersion: "20",
+				Distro:         "ubi8",
+				OSVersion:      "8",
+				BaseRunImage:   "registry.access.redhat.com/ubi8/nodejs-20-minimal@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			}))
+		})
+	})
+
+	context("ExpandArgs", func() {
+		var variables descriptor.Variables
+
+		it.Before(func() {
+			var err error
+			variables, err = descriptor.VariablesFor(imagesJson, imagesJson.StackImages[0])
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it("evaluates args against the variables without touching the original maps", func() {
+			stack := descriptor.Stack{
+				Build: descriptor.Image{Args: map[string]string{"DISTRO": "ubi{{.OSVersion}}"}},
+				Run: descriptor.Image{Args: map[string]string{
+					"BASE_IMAGE":     "{{.BaseRunImage.Repository}}@{{.BaseRunImage.Digest}}",
+					"NODEJS_VERSION": "{{.RuntimeVersion}}",
+					"PLAIN":          "value",
+				}},
+			}
+
+			expanded, err := descriptor.ExpandArgs(stack, variables)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expanded.Build.Args).To(Equal(map[string]string{"DISTRO": "ubi8"}))
+			Expect(expanded.Run.Args).To(Equal(map[string]string{
+				"BASE_IMAGE":     "registry.access.redhat.com/ubi8/nodejs-20-minimal@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+				"NODEJS_VERSION": "20",
+				"PLAIN":          "value",
+			}))
+
+			Expect(stack.Run.Args["NODEJS_VERSION"]).To(Equal("{{.RuntimeVersion}}"))
+		})
+
+		context("failure cases", func() {
+			it("reports every arg that does not expand", func() {
+				_, err := descriptor.ExpandArgs(descriptor.Stack{
+					Build: descriptor.Image{Args: map[string]string{"BASE_IMAGE": "{{.BaseBuildImage.Tag}}"}},
+					Run: descriptor.Image{Args: map[string]string{
+						"TAG":     "{{.BaseRunImage.Tag}}",
+						"UNKNOWN": "{{.NodeVersion}}",
+						"BROKEN":  "{{.Name",
+					}},
+				}, variables)
+				Expect(err).To(MatchError(SatisfyAll(
+					ContainSubstring("nodejs-20: build.args.BASE_IMAGE:"),
+					ContainSubstring("no base image is declared"),
+					ContainSubstring("nodejs-20: run.args.BROKEN:"),
+					ContainSubstring("nodejs-20: run.args.TAG:"),
+					ContainSubstring("is pinned to a digest, not a tag"),
+					ContainSubstring("nodejs-20: run.args.UNKNOWN:"),
+					ContainSubstring("can't evaluate field NodeVersion"),
+				)))
+			})
+		})
+	})
+}
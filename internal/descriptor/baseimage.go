@@ -0,0 +1,246 @@
+package descriptor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+)
+
+// DockerTransport is the prefix images.json puts in front of base image
+// references.
+const DockerTransport = "docker://"
+
+// BaseImage pairs the base image an images.json entry declares for one of
+// its images with the image the corresponding Dockerfile is built from.
+type BaseImage struct {
+	Name string
+	// Kind is either build or run.
+	Kind string
+	// Field is the images.json key that declares the base image.
+	Field string
+	// Dockerfile is the path of the Dockerfile relative to the repository
+	// root.
+	Dockerfile string
+	Declared   string
+	// From is the image of the FROM line with build arguments substituted.
+	From string
+
+	line int
+	// templated is set when From comes from a build argument, in which case
+	// the Dockerfile has nothing to rewrite.
+	templated bool
+}
+
+// InSync reports whether both references name the same image once default
+// registries and tags are filled in.
+func (b BaseImage) InSync() (bool, error) {
+	declared, err := name.ParseReference(strings.TrimPrefix(b.Declared, DockerTransport))
+	if err != nil {
+		return false, fmt.Errorf("%s: %s %q: %w", b.Name, b.Field, b.Declared, err)
+	}
+
+	from, err := name.ParseReference(b.From)
+	if err != nil {
+		return false, fmt.Errorf("%s: FROM %q in %s: %w", b.Name, b.From, b.Dockerfile, err)
+	}
+
+	return declared.Name() == from.Name(), nil
+}
+
+// BaseImages lists the base images of every entry that declares one. Build
+// images are only listed for entries that set base_build_container_image.
+// FROM lines that use build arguments are resolved with the expanded args of
+// the entry.
+func BaseImages(root string, imagesJson images.ImagesJson) ([]BaseImage, error) {
+	stacks, err := LoadExpanded(root, imagesJson)
+	if err != nil {
+		return nil, err
+	}
+
+	var baseImages []BaseImage
+	for _, image := range imagesJson.StackImages {
+		stack := stacks[image.Name]
+
+		sections := []struct {
+			kind     string
+			field    string
+			declared string
+			image    Image
+		}{
+			{kind: "build", field: "base_build_container_image", declared: image.BaseBuildContainerImage, image: stack.Build},
+			{kind: "run", field: "base_run_container_image", declared: image.BaseRunContainerImage, image: stack.Run},
+		}
+
+		for _, section := range sections {
+			if section.declared == "" {
+				continue
+			}
+
+			dockerfile := filepath.Join(image.ConfigDir, section.image.Dockerfile)
+			from, line, templated, err := baseImage(filepath.Join(root, dockerfile), section.image.Args)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", image.Name, err)
+			}
+
+			baseImages = append(baseImages, BaseImage{
+				Name:       image.Name,
+				Kind:       section.kind,
+				Field:      section.field,
+				Dockerfile: filepath.Clean(dockerfile),
+				Declared:   section.declared,
+				From:       from,
+				line:       line,
+				templated:  templated,
+			})
+		}
+	}
+
+	return baseImages, nil
+}
+
+// CheckBaseImages fails when any entry declares a base image its Dockerfile
+// is not built from.
+func CheckBaseImages(root string, imagesJson images.ImagesJson) error {
+	baseImages, err := BaseImages(root, imagesJson)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, baseImage := range baseImages {
+		inSync, err := baseImage.InSync()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if !inSync {
+			errs = append(errs, fmt.Errorf("%s: %s %q does not match FROM %q in %s", baseImage.Name, baseImage.Field, baseImage.Declared, baseImage.From, baseImage.Dockerfile))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// SyncDockerfile rewrites the FROM line of the Dockerfile to the declared
+// base image.
+func (b BaseImage) SyncDockerfile(root string) error {
+	if b.templated {
+		return fmt.Errorf("%s: FROM in %s is set by build arguments, fix the args or %s instead", b.Name, b.Dockerfile, b.Field)
+	}
+
+	path := filepath.Join(root, b.Dockerfile)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// the image may sit on a continuation line of the FROM instruction
+	lines := strings.Split(string(content), "\n")
+	for i := b.line; i < len(lines); i++ {
+		if strings.Contains(lines[i], b.From) {
+			lines[i] = strings.Replace(lines[i], b.From, strings.TrimPrefix(b.Declared, DockerTransport), 1)
+			break
+		}
+	}
+
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644)
+}
+
+// baseImage returns the external image the final stage of a Dockerfile is
+// built from, following references to earlier stages, along with the index
+// of the line that names it and whether build arguments were substituted.
+// Arguments take their value from args, then from the default of the ARG
+// instructions that precede the first stage.
+func baseImage(path string, args map[string]string) (string, int, bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, false, err
+	}
+
+	type stage struct {
+		image string
+		alias string
+		line  int
+	}
+
+	var stages []stage
+	defaults := map[string]string{}
+	lines := strings.Split(string(content), "\n")
+	for index := 0; index < len(lines); index++ {
+		start := index
+		instruction := strings.TrimSpace(lines[index])
+		for strings.HasSuffix(instruction, "\\") && index+1 < len(lines) {
+			index++
+			instruction = strings.TrimSuffix(instruction, "\\") + " " + strings.TrimSpace(lines[index])
+		}
+
+		fields := strings.Fields(instruction)
+		if len(fields) > 1 && strings.EqualFold(fields[0], "ARG") && len(stages) == 0 {
+			key, value, _ := strings.Cut(fields[1], "=")
+			if _, ok := defaults[key]; !ok {
+				defaults[key] = strings.Trim(value, `"'`)
+			}
+			continue
+		}
+
+		if len(fields) == 0 || !strings.EqualFold(fields[0], "FROM") {
+			continue
+		}
+
+		var arguments []string
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "--") {
+				arguments = append(arguments, field)
+			}
+		}
+
+		if len(arguments) == 0 {
+			return "", 0, false, fmt.Errorf("%s:%d: FROM without an image", path, start+1)
+		}
+
+		s := stage{image: arguments[0], line: start}
+		if len(arguments) == 3 && strings.EqualFold(arguments[1], "AS") {
+			s.alias = arguments[2]
+		}
+		stages = append(stages, s)
+	}
+
+	if len(stages) == 0 {
+		return "", 0, false, fmt.Errorf("%s has no FROM instruction", path)
+	}
+
+	current := stages[len(stages)-1]
+	for i := len(stages) - 2; i >= 0; i-- {
+		if stages[i].alias != "" && strings.EqualFold(stages[i].alias, current.image) {
+			current = stages[i]
+		}
+	}
+
+	if !strings.Contains(current.image, "$") {
+		return current.image, current.line, false, nil
+	}
+
+	var unset []string
+	image := os.Expand(current.image, func(key string) string {
+		if value, ok := args[key]; ok {
+			return value
+		}
+		if value := defaults[key]; value != "" {
+			return value
+		}
+		unset = append(unset, key)
+		return ""
+	})
+
+	if len(unset) > 0 {
+		return "", 0, false, fmt.Errorf("%s:%d: FROM %q depends on build arguments %q that are not set", path, current.line+1, current.image, unset)
+	}
+
+	return image, current.line, true, nil
+}
@@ -0,0 +1,149 @@
+package descriptor_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-community/ubi-base-stack/internal/descriptor"
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testBaseImage(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		root       string
+		imagesJson images.ImagesJson
+	)
+
+	const stackToml = `id = "io.buildpacks.stacks.ubi8"
+homepage = "https://github.com/paketo-community/ubi-base-stack"
+maintainer = "Paketo Community"
+platforms = ["linux/amd64"]
+
+[build]
+  description = "build"
+  dockerfile = "./build.Dockerfile"
+
+[run]
+  description = "run"
+  dockerfile = "./run.Dockerfile"
+`
+
+	writeFile := func(path, content string) {
+		Expect(os.MkdirAll(filepath.Dir(filepath.Join(root, path)), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, path), []byte(content), 0644)).To(Succeed())
+	}
+
+	it.Before(func() {
+		var err error
+		root, err = os.MkdirTemp("", "root")
+		Expect(err).NotTo(HaveOccurred())
+
+		writeFile("stacks/stack/stack.toml", stackToml)
+		writeFile("stacks/stack/build.Dockerfile", "FROM registry.access.redhat.com/ubi8/ubi-minimal:latest\nUSER root\n")
+		writeFile("stacks/stack/run.Dockerfile", "# syntax=docker/dockerfile:1\nFROM --platform=$BUILDPLATFORM registry.access.redhat.com/ubi8/ubi-minimal AS base\n\nFROM base\nUSER 1001\n")
+
+		imagesJson = images.ImagesJson{
+			Distros: map[string]images.Distro{
+				"ubi8": {StackID: "io.buildpacks.stacks.ubi8", OSVersion: "8"},
+			},
+			StackImages: []images.StackImages{
+				{
+					Name:                    "default",
+					ConfigDir:               "stacks/stack",
+					Distro:                  "ubi8",
+					BaseBuildContainerImage: "docker://registry.access.redhat.com/ubi8/ubi-minimal",
+					BaseRunContainerImage:   "docker://registry.access.redhat.com/ubi8/ubi-minimal:latest",
+				},
+			},
+		}
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	context("BaseImages", func() {
+		it("pairs the declared base images with the final stage of each Dockerfile", func() {
+			baseImages, err := descriptor.BaseImages(root, imagesJson)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(baseImages).To(HaveLen(2))
+
+			Expect(baseImages[0].Kind).To(Equal("build"))
+			Expect(baseImages[0].Field).To(Equal("base_build_container_image"))
+			Expect(baseImages[0].Dockerfile).To(Equal("stacks/stack/build.Dockerfile"))
+			Expect(baseImages[0].From).To(Equal("registry.access.redhat.com/ubi8/ubi-minimal:latest"))
+
+			Expect(baseImages[1].Kind).To(Equal("run"))
+			Expect(baseImages[1].From).To(Equal("registry.access.redhat.com/ubi8/ubi-minimal"))
+		})
+
+		it("skips build images that declare no base image", func() {
+			imagesJson.StackImages[0].BaseBuildContainerImage = ""
+
+			baseImages, err := descriptor.BaseImages(root, imagesJson)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(baseImages).To(HaveLen(1))
+			Expect(baseImages[0].Kind).To(Equal("run"))
+		})
+
+		it("resolves FROM lines from the expanded args and the ARG defaults", func() {
+			writeFile("stacks/stack/stack.toml", stackToml+`
+  [run.args]
+    BASE_IMAGE = "{{.BaseRunImage.Repository}}"
+`)
+			writeFile("stacks/stack/run.Dockerfile", "ARG BASE_IMAGE\nARG TAG=latest\nFROM ${BASE_IMAGE}:$TAG\n")
+
+			baseImages, err := descriptor.BaseImages(root, imagesJson)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(baseImages[1].From).To(Equal("registry.access.redhat.com/ubi8/ubi-minimal:latest"))
+
+			Expect(baseImages[1].SyncDockerfile(root)).To(MatchError("default: FROM in stacks/stack/run.Dockerfile is set by build arguments, fix the args or base_run_container_image instead"))
+		})
+
+		context("failure cases", func() {
+			it("rejects FROM lines that depend on unset build arguments", func() {
+				writeFile("stacks/stack/run.Dockerfile", "ARG BASE\nFROM ${BASE}\n")
+
+				_, err := descriptor.BaseImages(root, imagesJson)
+				Expect(err).To(MatchError(ContainSubstring(`run.Dockerfile:2: FROM "${BASE}" depends on build arguments ["BASE"] that are not set`)))
+			})
+		})
+	})
+
+	context("CheckBaseImages", func() {
+		it("accepts references that only differ in defaults", func() {
+			Expect(descriptor.CheckBaseImages(root, imagesJson)).To(Succeed())
+		})
+
+		context("failure cases", func() {
+			it("reports base images that drifted", func() {
+				imagesJson.StackImages[0].BaseRunContainerImage = "docker://registry.access.redhat.com/ubi9/ubi-minimal"
+
+				err := descriptor.CheckBaseImages(root, imagesJson)
+				Expect(err).To(MatchError(`default: base_run_container_image "docker://registry.access.redhat.com/ubi9/ubi-minimal" does not match FROM "registry.access.redhat.com/ubi8/ubi-minimal" in stacks/stack/run.Dockerfile`))
+			})
+		})
+	})
+
+	context("SyncDockerfile", func() {
+		it("rewrites the FROM line to the declared base image", func() {
+			imagesJson.StackImages[0].BaseRunContainerImage = "docker://registry.access.redhat.com/ubi9/ubi-minimal"
+
+			baseImages, err := descriptor.BaseImages(root, imagesJson)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(baseImages[1].SyncDockerfile(root)).To(Succeed())
+
+			content, err := os.ReadFile(filepath.Join(root, "stacks/stack/run.Dockerfile"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("# syntax=docker/dockerfile:1\nFROM --platform=$BUILDPLATFORM registry.access.redhat.com/ubi9/ubi-minimal AS base\n\nFROM base\nUSER 1001\n"))
+
+			Expect(descriptor.CheckBaseImages(root, imagesJson)).To(Succeed())
+		})
+	})
+}
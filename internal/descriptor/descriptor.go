@@ -0,0 +1,180 @@
+package descriptor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+)
+
+// Filename is the name of the stack descriptor inside each config_dir.
+const Filename = "stack.toml"
+
+// Stack models a stack.toml as consumed by `jam create-stack`. Parent and
+// Overrides only exist in the repository sources; they are resolved away
+// before the descriptor is handed to jam.
+type Stack struct {
+	Parent     string   `toml:"parent,omitempty"`
+	Overrides  []string `toml:"overrides,omitempty"`
+	ID         string   `toml:"id"`
+	Homepage   string   `toml:"homepage"`
+	Maintainer string   `toml:"maintainer"`
+	Platforms  []string `toml:"platforms"`
+	Build      Image    `toml:"build"`
+	Run        Image    `toml:"run"`
+}
+
+// Image models the [build] and [run] sections of a stack.toml.
+type Image struct {
+	Description string            `toml:"description"`
+	Dockerfile  string            `toml:"dockerfile"`
+	GID         int               `toml:"gid"`
+	Shell       string            `toml:"shell"`
+	UID         int               `toml:"uid"`
+	Args        map[string]string `toml:"args"`
+}
+
+// Load parses the stack.toml at path and resolves its parent chain, so the
+// returned descriptor is fully materialized. Dockerfile paths stay relative
+// to the directory of path.
+func Load(path string) (Stack, error) {
+	return resolveChain(path, map[string]bool{})
+}
+
+// decode parses a single stack.toml, rejecting any key that is not part of
+// the model.
+func decode(path string) (Stack, toml.MetaData, error) {
+	var stack Stack
+	metadata, err := toml.DecodeFile(path, &stack)
+	if err != nil {
+		return Stack{}, toml.MetaData{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+		var keys []string
+		for _, key := range undecoded {
+			keys = append(keys, key.String())
+		}
+		return Stack{}, toml.MetaData{}, fmt.Errorf("failed to parse %s: unknown keys %q", path, keys)
+	}
+
+	return stack, metadata, nil
+}
+
+// LoadAll parses the stack.toml of every entry in images.json, keyed by
+// entry name.
+func LoadAll(root string, imagesJson images.ImagesJson) (map[string]Stack, error) {
+	stacks := map[string]Stack{}
+	for _, image := range imagesJson.StackImages {
+		stack, err := Load(filepath.Join(root, image.ConfigDir, Filename))
+		if err != nil {
+			return nil, err
+		}
+		stacks[image.Name] = stack
+	}
+
+	return stacks, nil
+}
+
+// Validate cross-checks the stack.toml of every images.json entry: the id
+// must be the stack id of its distro, dockerfile paths must resolve, every
+// variant must declare the same build section as the stack that provides the
+// build image of its distro, and args must expand for every variant.
+func Validate(root string, imagesJson images.ImagesJson) error {
+	stacks, err := LoadAll(root, imagesJson)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, image := range imagesJson.StackImages {
+		stack := stacks[image.Name]
+		configDir := filepath.Join(root, image.ConfigDir)
+
+		distro, err := imagesJson.Distro(image)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if stack.ID != distro.StackID {
+			errs = append(errs, fmt.Errorf("%s: id %q differs from the stack_id %q of distro %s", image.Name, stack.ID, distro.StackID, image.Distro))
+		}
+
+		buildStack, err := imagesJson.BuildStack(image.Distro)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		sections := []struct {
+			name  string
+			image Image
+		}{
+			{name: "build", image: stack.Build},
+			{name: "run", image: stack.Run},
+		}
+
+		for _, section := range sections {
+			if section.image.Dockerfile == "" {
+				errs = append(errs, fmt.Errorf("%s: [%s] dockerfile must not be empty", image.Name, section.name))
+				continue
+			}
+
+			_, err := os.Stat(filepath.Join(configDir, section.image.Dockerfile))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: [%s] dockerfile %q does not resolve", image.Name, section.name, section.image.Dockerfile))
+			}
+		}
+
+		if image.Name == buildStack.Name {
+			continue
+		}
+
+		expected := resolve(filepath.Join(root, buildStack.ConfigDir), stacks[buildStack.Name].Build)
+		actual := resolve(configDir, stack.Build)
+		if !reflect.DeepEqual(expected, actual) {
+			errs = append(errs, fmt.Errorf("%s: [build] section differs from %s: %s", image.Name, buildStack.Name, strings.Join(diff(expected, actual), ", ")))
+		}
+	}
+
+	_, err = LoadExpanded(root, imagesJson)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// resolve makes an image section comparable across config directories by
+// joining the dockerfile path onto the directory it is relative to.
+func resolve(configDir string, image Image) Image {
+	if image.Dockerfile != "" {
+		image.Dockerfile = filepath.Clean(filepath.Join(configDir, image.Dockerfile))
+	}
+
+	if len(image.Args) == 0 {
+		image.Args = nil
+	}
+
+	return image
+}
+
+func diff(expected, actual Image) []string {
+	var fields []string
+	expectedValue := reflect.ValueOf(expected)
+	actualValue := reflect.ValueOf(actual)
+	for i := 0; i < expectedValue.NumField(); i++ {
+		if !reflect.DeepEqual(expectedValue.Field(i).Interface(), actualValue.Field(i).Interface()) {
+			fields = append(fields, strings.Split(expectedValue.Type().Field(i).Tag.Get("toml"), ",")[0])
+		}
+	}
+
+	return fields
+}
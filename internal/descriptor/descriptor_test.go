@@ -0,0 +1,202 @@
+package descriptor_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-community/ubi-base-stack/internal/descriptor"
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testDescriptor(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		root       string
+		imagesJson images.ImagesJson
+	)
+
+	const defaultStackToml = `id = "io.buildpacks.stacks.ubi8"
+homepage = "https://github.com/paketo-community/ubi-base-stack"
+maintainer = "Paketo Community"
+
+platforms = ["linux/amd64", "linux/arm64"]
+
+[build]
+  description = "base build ubi8 image to support buildpacks"
+  dockerfile = "./build.Dockerfile"
+  gid = 1000
+  shell = "/bin/bash"
+  uid = 1002
+
+  [build.args]
+
+[run]
+  description = "base run ubi8 image to support buildpacks"
+  dockerfile = "./run.Dockerfile"
+  gid = 1000
+  shell = "/bin/bash"
+  uid = 1001
+
+  [run.args]
+`
+
+	writeStack := func(dir string, stackToml string, dockerfiles ...string) {
+		Expect(os.MkdirAll(filepath.Join(root, dir), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, dir, descriptor.Filename), []byte(stackToml), 0644)).To(Succeed())
+		for _, dockerfile := range dockerfiles {
+			Expect(os.WriteFile(filepath.Join(root, dir, dockerfile), []byte("FROM scratch\n"), 0644)).To(Succeed())
+		}
+	}
+
+	it.Before(func() {
+		var err error
+		root, err = os.MkdirTemp("", "root")
+		Expect(err).NotTo(HaveOccurred())
+
+		writeStack("stacks/stack", defaultStackToml, "build.Dockerfile", "run.Dockerfile")
+
+		imagesJson = images.ImagesJson{
+			Distros: map[string]images.Distro{
+				"ubi8": {StackID: "io.buildpacks.stacks.ubi8"},
+				"ubi9": {StackID: "io.buildpacks.stacks.ubi9"},
+			},
+			StackImages: []images.StackImages{
+				{Name: "default", ConfigDir: "stacks/stack", CreateBuildImage: true, Distro: "ubi8"},
+				{Name: "nodejs-20", ConfigDir: "stacks/stack-nodejs-20", IsDefaultRunImage: true, Distro: "ubi8"},
+			},
+		}
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	context("Load", func() {
+		it("parses every section of the descriptor", func() {
+			stack, err := descriptor.Load(filepath.Join(root, "stacks/stack", descriptor.Filename))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stack).To(Equal(descriptor.Stack{
+				ID:         "io.buildpacks.stacks.ubi8",
+				Homepage:   "https://github.com/paketo-community/ubi-base-stack",
+				Maintainer: "Paketo Community",
+				Platforms:  []string{"linux/amd64", "linux/arm64"},
+				Build: descriptor.Image{
+					Description: "base build ubi8 image to support buildpacks",
+					Dockerfile:  "./build.Dockerfile",
+					GID:         1000,
+					Shell:       "/bin/bash",
+					UID:         1002,
+					Args:        map[string]string{},
+				},
+				Run: descriptor.Image{
+					Description: "base run ubi8 image to support buildpacks",
+					Dockerfile:  "./run.Dockerfile",
+					GID:         1000,
+					Shell:       "/bin/bash",
+					UID:         1001,
+					Args:        map[string]string{},
+				},
+			}))
+		})
+
+		it("rejects unknown keys", func() {
+			writeStack("stacks/stack-typo", "id = \"io.buildpacks.stacks.ubi8\"\nplatform = [\"linux/amd64\"]\n")
+
+			_, err := descriptor.Load(filepath.Join(root, "stacks/stack-typo", descriptor.Filename))
+			Expect(err).To(MatchError(ContainSubstring(`unknown keys ["platform"]`)))
+		})
+	})
+
+	context("Validate", func() {
+		it("accepts variants that share the build section through a relative path", func() {
+			writeStack("stacks/stack-nodejs-20", `id = "io.buildpacks.stacks.ubi8"
+
+[build]
+  description = "base build ubi8 image to support buildpacks"
+  dockerfile = "../stack/build.Dockerfile"
+  gid = 1000
+  shell = "/bin/bash"
+  uid = 1002
+
+[run]
+  description = "ubi8 nodejs-20 image to support buildpacks"
+  dockerfile = "./run.Dockerfile"
+  gid = 1000
+  shell = "/bin/bash"
+  uid = 1001
+`, "run.Dockerfile")
+
+			Expect(descriptor.Validate(root, imagesJson)).To(Succeed())
+		})
+
+		it("reports unresolved dockerfiles and drifting build sections", func() {
+			writeStack("stacks/stack-nodejs-20", `id = "io.buildpacks.stacks.ubi8"
+
+[build]
+  description = "base build ubi8 image to support buildpacks"
+  dockerfile = "./build.Dockerfile"
+  gid = 1000
+  shell = "/bin/bash"
+  uid = 1003
+
+[run]
+  dockerfile = "./run.Dockerfile"
+`)
+
+			err := descriptor.Validate(root, imagesJson)
+			Expect(err).To(MatchError(SatisfyAll(
+				ContainSubstring(`nodejs-20: [build] dockerfile "./build.Dockerfile" does not resolve`),
+				ContainSubstring(`nodejs-20: [run] dockerfile "./run.Dockerfile" does not resolve`),
+				ContainSubstring(`nodejs-20: [build] section differs from default: dockerfile, uid`),
+			)))
+		})
+
+		it("compares variants with the build stack of their own distro", func() {
+			writeStack("stacks/stack-ubi9", `id = "io.buildpacks.stacks.ubi9"
+
+[build]
+  description = "base build ubi9 image to support buildpacks"
+  dockerfile = "./build.Dockerfile"
+  uid = 1002
+
+[run]
+  description = "base run ubi9 image to support buildpacks"
+  dockerfile = "./run.Dockerfile"
+  uid = 1001
+`, "build.Dockerfile", "run.Dockerfile")
+			writeStack("stacks/stack-nodejs-20", `id = "io.buildpacks.stacks.ubi9"
+
+[build]
+  description = "base build ubi9 image to support buildpacks"
+  dockerfile = "../stack-ubi9/build.Dockerfile"
+  uid = 1002
+
+[run]
+  description = "ubi9 nodejs-20 image to support buildpacks"
+  dockerfile = "./run.Dockerfile"
+  uid = 1001
+`, "run.Dockerfile")
+
+			imagesJson.StackImages[1].Distro = "ubi9"
+			imagesJson.StackImages = append(imagesJson.StackImages,
+				images.StackImages{Name: "default-ubi9", ConfigDir: "stacks/stack-ubi9", CreateBuildImage: true, Distro: "ubi9"},
+			)
+
+			Expect(descriptor.Validate(root, imagesJson)).To(Succeed())
+		})
+
+		it("reports ids that differ from the stack id of the distro", func() {
+			imagesJson.StackImages[0].Distro = "ubi9"
+			imagesJson.StackImages[1].Distro = "ubi9"
+			writeStack("stacks/stack-nodejs-20", defaultStackToml, "run.Dockerfile")
+
+			err := descriptor.Validate(root, imagesJson)
+			Expect(err).To(MatchError(ContainSubstring(`default: id "io.buildpacks.stacks.ubi8" differs from the stack_id "io.buildpacks.stacks.ubi9" of distro ubi9`)))
+		})
+	})
+}
@@ -0,0 +1,203 @@
+package descriptor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// field is a leaf key of a stack.toml, such as ["build", "uid"].
+type field struct {
+	key   []string
+	index []int
+}
+
+func (f field) String() string {
+	return strings.Join(f.key, ".")
+}
+
+// fields lists every inheritable key of the Stack model. Parent and Overrides
+// describe the inheritance itself and are never inherited.
+func fields() []field {
+	var result []field
+
+	var walk func(t reflect.Type, key []string, index []int)
+	walk = func(t reflect.Type, key []string, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			structField := t.Field(i)
+			name := strings.Split(structField.Tag.Get("toml"), ",")[0]
+			if name == "parent" || name == "overrides" {
+				continue
+			}
+
+			fieldKey := append(append([]string{}, key...), name)
+			fieldIndex := append(append([]int{}, index...), i)
+
+			if structField.Type.Kind() == reflect.Struct {
+				walk(structField.Type, fieldKey, fieldIndex)
+				continue
+			}
+
+			result = append(result, field{key: fieldKey, index: fieldIndex})
+		}
+	}
+	walk(reflect.TypeOf(Stack{}), nil, nil)
+
+	return result
+}
+
+// resolveChain loads the stack.toml at path and merges it onto its resolved
+// parent. A child may always redefine its [run] section; any other key that
+// differs from the parent must be listed in overrides, either by its full
+// name ("build.uid") or by its section ("build").
+func resolveChain(path string, visited map[string]bool) (Stack, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return Stack{}, err
+	}
+
+	if visited[absPath] {
+		return Stack{}, fmt.Errorf("failed to resolve %s: parent chain contains a cycle", path)
+	}
+	visited[absPath] = true
+
+	child, metadata, err := decode(path)
+	if err != nil {
+		return Stack{}, err
+	}
+
+	if child.Parent == "" {
+		if len(child.Overrides) > 0 {
+			return Stack{}, fmt.Errorf("failed to resolve %s: overrides require a parent", path)
+		}
+		return child, nil
+	}
+
+	dir := filepath.Dir(path)
+	parentPath := filepath.Join(dir, child.Parent)
+	parent, err := resolveChain(parentPath, visited)
+	if err != nil {
+		return Stack{}, err
+	}
+
+	parent.Build.Dockerfile = rebase(filepath.Dir(parentPath), dir, parent.Build.Dockerfile)
+	parent.Run.Dockerfile = rebase(filepath.Dir(parentPath), dir, parent.Run.Dockerfile)
+
+	overrides := map[string]bool{}
+	for _, override := range child.Overrides {
+		overrides[override] = true
+	}
+
+	var errs []error
+
+	merged := parent
+	mergedValue := reflect.ValueOf(&merged).Elem()
+	childValue := reflect.ValueOf(child)
+	parentValue := reflect.ValueOf(parent)
+
+	for _, f := range fields() {
+		if !metadata.IsDefined(f.key...) {
+			continue
+		}
+
+		value := childValue.FieldByIndex(f.index)
+		section := f.key[0]
+
+		if section != "run" && !overrides[f.String()] && !overrides[section] && !equal(f, parentValue.FieldByIndex(f.index), value) {
+			errs = append(errs, fmt.Errorf("%s: %s drifts from parent %s without an explicit override", path, f, child.Parent))
+		}
+
+		mergedValue.FieldByIndex(f.index).Set(value)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return Stack{}, err
+	}
+
+	return merged, nil
+}
+
+func equal(f field, expected, actual reflect.Value) bool {
+	if f.key[len(f.key)-1] == "dockerfile" {
+		return filepath.Clean(expected.String()) == filepath.Clean(actual.String())
+	}
+
+	if expected.Kind() == reflect.Map || expected.Kind() == reflect.Slice {
+		if expected.Len() == 0 && actual.Len() == 0 {
+			return true
+		}
+	}
+
+	return reflect.DeepEqual(expected.Interface(), actual.Interface())
+}
+
+// rebase rewrites a dockerfile path that is relative to from so that it is
+// relative to to.
+func rebase(from, to, dockerfile string) string {
+	if dockerfile == "" || filepath.IsAbs(dockerfile) {
+		return dockerfile
+	}
+
+	absFrom, err := filepath.Abs(from)
+	if err != nil {
+		return dockerfile
+	}
+
+	absTo, err := filepath.Abs(to)
+	if err != nil {
+		return dockerfile
+	}
+
+	rel, err := filepath.Rel(absTo, filepath.Join(absFrom, dockerfile))
+	if err != nil {
+		return dockerfile
+	}
+
+	if !strings.HasPrefix(rel, "..") {
+		rel = "./" + rel
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// Materialize resolves the stack.toml at path and writes the result to
+// output, with dockerfile paths rewritten relative to the output location so
+// that `jam create-stack --config output` finds them. Args are expanded
+// against variables unless they are nil.
+func Materialize(path, output string, variables *Variables) error {
+	stack, err := Load(path)
+	if err != nil {
+		return err
+	}
+
+	if variables != nil {
+		stack, err = ExpandArgs(stack, *variables)
+		if err != nil {
+			return err
+		}
+	}
+
+	stack.Build.Dockerfile = rebase(filepath.Dir(path), filepath.Dir(output), stack.Build.Dockerfile)
+	stack.Run.Dockerfile = rebase(filepath.Dir(path), filepath.Dir(output), stack.Run.Dockerfile)
+
+	buffer := bytes.NewBuffer(nil)
+	fmt.Fprintf(buffer, "# Generated from %s. DO NOT EDIT.\n\n", path)
+
+	err = toml.NewEncoder(buffer).Encode(stack)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(output), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(output, buffer.Bytes(), 0644)
+}
@@ -0,0 +1,185 @@
+package descriptor_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-community/ubi-base-stack/internal/descriptor"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testInherit(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		root string
+	)
+
+	write := func(path string, content string) {
+		Expect(os.MkdirAll(filepath.Join(root, filepath.Dir(path)), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, path), []byte(content), 0644)).To(Succeed())
+	}
+
+	it.Before(func() {
+		var err error
+		root, err = os.MkdirTemp("", "root")
+		Expect(err).NotTo(HaveOccurred())
+
+		write("stacks/stack/stack.toml", `id = "io.buildpacks.stacks.ubi8"
+homepage = "https://github.com/paketo-community/ubi-base-stack"
+maintainer = "Paketo Community"
+
+platforms = ["linux/amd64", "linux/arm64"]
+
+[build]
+  description = "base build ubi8 image to support buildpacks"
+  dockerfile = "./build.Dockerfile"
+  gid = 1000
+  shell = "/bin/bash"
+  uid = 1002
+
+  [build.args]
+
+[run]
+  description = "base run ubi8 image to support buildpacks"
+  dockerfile = "./run.Dockerfile"
+  gid = 1000
+  shell = "/bin/bash"
+  uid = 1001
+
+  [run.args]
+`)
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	context("Load", func() {
+		it("inherits everything but the overridden run section from the parent", func() {
+			write("stacks/stack-nodejs-20/stack.toml", `parent = "../stack/stack.toml"
+
+[run]
+  description = "ubi8 nodejs-20 image to support buildpacks"
+  dockerfile = "./run.Dockerfile"
+`)
+
+			stack, err := descriptor.Load(filepath.Join(root, "stacks/stack-nodejs-20/stack.toml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stack.Parent).To(BeEmpty())
+			Expect(stack.ID).To(Equal("io.buildpacks.stacks.ubi8"))
+			Expect(stack.Platforms).To(Equal([]string{"linux/amd64", "linux/arm64"}))
+			Expect(stack.Build.Dockerfile).To(Equal("../stack/build.Dockerfile"))
+			Expect(stack.Build.UID).To(Equal(1002))
+			Expect(stack.Run.Description).To(Equal("ubi8 nodejs-20 image to support buildpacks"))
+			Expect(stack.Run.Dockerfile).To(Equal("./run.Dockerfile"))
+			Expect(stack.Run.UID).To(Equal(1001))
+		})
+
+		it("accepts drift that is declared as an override", func() {
+			write("stacks/stack-arm/stack.toml", `parent = "../stack/stack.toml"
+overrides = ["platforms", "build"]
+
+platforms = ["linux/arm64"]
+
+[build]
+  uid = 1003
+`)
+
+			stack, err := descriptor.Load(filepath.Join(root, "stacks/stack-arm/stack.toml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stack.Platforms).To(Equal([]string{"linux/arm64"}))
+			Expect(stack.Build.UID).To(Equal(1003))
+			Expect(stack.Build.GID).To(Equal(1000))
+		})
+
+		it("resolves multi-level chains", func() {
+			write("stacks/stack-nodejs/stack.toml", `parent = "../stack/stack.toml"
+
+[run]
+  description = "ubi8 nodejs image to support buildpacks"
+`)
+			write("stacks/stack-nodejs-20/stack.toml", `parent = "../stack-nodejs/stack.toml"
+
+[run]
+  dockerfile = "./run.Dockerfile"
+`)
+
+			stack, err := descriptor.Load(filepath.Join(root, "stacks/stack-nodejs-20/stack.toml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stack.Build.Dockerfile).To(Equal("../stack/build.Dockerfile"))
+			Expect(stack.Run.Description).To(Equal("ubi8 nodejs image to support buildpacks"))
+			Expect(stack.Run.Dockerfile).To(Equal("./run.Dockerfile"))
+		})
+
+		context("failure cases", func() {
+			it("flags fields that drift from the parent without an override", func() {
+				write("stacks/stack-java-8/stack.toml", `parent = "../stack/stack.toml"
+maintainer = "Someone Else"
+
+[build]
+  dockerfile = "../stack/build.Dockerfile"
+  uid = 1003
+`)
+
+				_, err := descriptor.Load(filepath.Join(root, "stacks/stack-java-8/stack.toml"))
+				Expect(err).To(MatchError(SatisfyAll(
+					ContainSubstring("maintainer drifts from parent ../stack/stack.toml without an explicit override"),
+					ContainSubstring("build.uid drifts from parent"),
+					Not(ContainSubstring("build.dockerfile")),
+				)))
+			})
+
+			it("detects cycles", func() {
+				write("stacks/a/stack.toml", `parent = "../b/stack.toml"`)
+				write("stacks/b/stack.toml", `parent = "../a/stack.toml"`)
+
+				_, err := descriptor.Load(filepath.Join(root, "stacks/a/stack.toml"))
+				Expect(err).To(MatchError(ContainSubstring("parent chain contains a cycle")))
+			})
+		})
+	})
+
+	context("Materialize", func() {
+		it("writes a descriptor jam can consume from the output location", func() {
+			write("stacks/stack-nodejs-20/stack.toml", `parent = "../stack/stack.toml"
+
+[run]
+  description = "ubi8 nodejs-20 image to support buildpacks"
+  dockerfile = "./run.Dockerfile"
+`)
+
+			output := filepath.Join(root, "builds/build-nodejs-20/stack.toml")
+			Expect(descriptor.Materialize(filepath.Join(root, "stacks/stack-nodejs-20/stack.toml"), output, nil)).To(Succeed())
+
+			stack, err := descriptor.Load(output)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stack.Parent).To(BeEmpty())
+			Expect(stack.ID).To(Equal("io.buildpacks.stacks.ubi8"))
+			Expect(stack.Build.Dockerfile).To(Equal("../../stacks/stack/build.Dockerfile"))
+			Expect(stack.Run.Dockerfile).To(Equal("../../stacks/stack-nodejs-20/run.Dockerfile"))
+		})
+
+		it("expands the args of the variant", func() {
+			write("stacks/stack-nodejs-20/stack.toml", `parent = "../stack/stack.toml"
+
+[run]
+  description = "ubi8 nodejs-20 image to support buildpacks"
+  dockerfile = "./run.Dockerfile"
+
+  [run.args]
+    NODEJS_VERSION = "{{.RuntimeVersion}}"
+`)
+
+			output := filepath.Join(root, "builds/build-nodejs-20/stack.toml")
+			Expect(descriptor.Materialize(filepath.Join(root, "stacks/stack-nodejs-20/stack.toml"), output, &descriptor.Variables{RuntimeVersion: "20"})).To(Succeed())
+
+			stack, err := descriptor.Load(output)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stack.Run.Args).To(Equal(map[string]string{"NODEJS_VERSION": "20"}))
+		})
+	})
+}
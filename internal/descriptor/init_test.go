@@ -0,0 +1,18 @@
+package descriptor_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitDescriptor(t *testing.T) {
+	suite := spec.New("descriptor", spec.Report(report.Terminal{}))
+	suite("Args", testArgs)
+	suite("BaseImage", testBaseImage)
+	suite("Descriptor", testDescriptor)
+	suite("Inherit", testInherit)
+	suite("Reconcile", testReconcile)
+	suite.Run(t)
+}
@@ -0,0 +1,60 @@
+package descriptor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+)
+
+// ReceiptFilename returns the receipt filename an image is expected to use.
+func ReceiptFilename(image string) string {
+	return fmt.Sprintf("%s-receipt.cyclonedx.json", image)
+}
+
+// Reconcile cross-checks the directories next to images.json with its
+// entries. It reports directories no entry points at, entries whose
+// config_dir holds no stack.toml and receipt filenames that do not follow the
+// <image>-receipt.cyclonedx.json convention.
+func Reconcile(root string, imagesJson images.ImagesJson) error {
+	var errs []error
+
+	configDirs := map[string]bool{}
+	for _, stack := range imagesJson.StackImages {
+		configDirs[filepath.Clean(stack.ConfigDir)] = true
+
+		_, err := os.Stat(filepath.Join(root, stack.ConfigDir, Filename))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: config_dir %q has no %s", stack.Name, stack.ConfigDir, Filename))
+		}
+
+		if expected := ReceiptFilename(stack.BuildImage); stack.BuildReceiptFilename != expected {
+			errs = append(errs, fmt.Errorf("%s: build_receipt_filename %q should be %q", stack.Name, stack.BuildReceiptFilename, expected))
+		}
+
+		if expected := ReceiptFilename(stack.RunImage); stack.RunReceiptFilename != expected {
+			errs = append(errs, fmt.Errorf("%s: run_receipt_filename %q should be %q", stack.Name, stack.RunReceiptFilename, expected))
+		}
+	}
+
+	stacksDir := filepath.Dir(images.Filename)
+	entries, err := os.ReadDir(filepath.Join(root, stacksDir))
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		dir := filepath.Join(stacksDir, entry.Name())
+		if !configDirs[dir] {
+			errs = append(errs, fmt.Errorf("%s is not the config_dir of any entry in %s", dir, images.Filename))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,90 @@
+package descriptor_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-community/ubi-base-stack/internal/descriptor"
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testReconcile(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		root       string
+		imagesJson images.ImagesJson
+	)
+
+	it.Before(func() {
+		var err error
+		root, err = os.MkdirTemp("", "root")
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, dir := range []string{"stacks/stack", "stacks/stack-nodejs-20"} {
+			Expect(os.MkdirAll(filepath.Join(root, dir), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(root, dir, descriptor.Filename), nil, 0644)).To(Succeed())
+		}
+
+		imagesJson = images.ImagesJson{
+			StackImages: []images.StackImages{
+				{
+					Name:                 "default",
+					ConfigDir:            "stacks/stack",
+					BuildImage:           "build",
+					RunImage:             "run",
+					BuildReceiptFilename: "build-receipt.cyclonedx.json",
+					RunReceiptFilename:   "run-receipt.cyclonedx.json",
+				},
+				{
+					Name:                 "nodejs-20",
+					ConfigDir:            "./stacks/stack-nodejs-20/",
+					BuildImage:           "build-nodejs-20",
+					RunImage:             "run-nodejs-20",
+					BuildReceiptFilename: "build-nodejs-20-receipt.cyclonedx.json",
+					RunReceiptFilename:   "run-nodejs-20-receipt.cyclonedx.json",
+				},
+			},
+		}
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	it("accepts directories and entries that match", func() {
+		Expect(descriptor.Reconcile(root, imagesJson)).To(Succeed())
+	})
+
+	context("failure cases", func() {
+		it("reports orphan directories", func() {
+			Expect(os.MkdirAll(filepath.Join(root, "stacks", "stack-foo"), os.ModePerm)).To(Succeed())
+
+			err := descriptor.Reconcile(root, imagesJson)
+			Expect(err).To(MatchError("stacks/stack-foo is not the config_dir of any entry in stacks/images.json"))
+		})
+
+		it("reports entries whose config_dir has no stack.toml", func() {
+			Expect(os.Remove(filepath.Join(root, "stacks", "stack-nodejs-20", descriptor.Filename))).To(Succeed())
+			imagesJson.StackImages[0].ConfigDir = "stacks/stack-gone"
+
+			err := descriptor.Reconcile(root, imagesJson)
+			Expect(err).To(MatchError(ContainSubstring(`default: config_dir "stacks/stack-gone" has no stack.toml`)))
+			Expect(err).To(MatchError(ContainSubstring(`nodejs-20: config_dir "./stacks/stack-nodejs-20/" has no stack.toml`)))
+			Expect(err).To(MatchError(ContainSubstring("stacks/stack is not the config_dir of any entry")))
+		})
+
+		it("reports receipt filenames that break the convention", func() {
+			imagesJson.StackImages[1].BuildReceiptFilename = "build-receipt.cyclonedx.json"
+			imagesJson.StackImages[1].RunReceiptFilename = "run-nodejs-20.cyclonedx.json"
+
+			err := descriptor.Reconcile(root, imagesJson)
+			Expect(err).To(MatchError(ContainSubstring(`nodejs-20: build_receipt_filename "build-receipt.cyclonedx.json" should be "build-nodejs-20-receipt.cyclonedx.json"`)))
+			Expect(err).To(MatchError(ContainSubstring(`nodejs-20: run_receipt_filename "run-nodejs-20.cyclonedx.json" should be "run-nodejs-20-receipt.cyclonedx.json"`)))
+		})
+	})
+}
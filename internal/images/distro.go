@@ -0,0 +1,59 @@
+package images
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// Distro describes an operating system family variants are built on, e.g.
+// ubi8 or ubi9. Each distro has its own build image and default run image.
+type Distro struct {
+	StackID string `json:"stack_id"`
+	// OSName is the ID of the distribution in /etc/os-release, e.g. rhel.
+	OSName string `json:"os_name"`
+	// OSPrettyName is the distribution name in the PRETTY_NAME of
+	// /etc/os-release, e.g. Red Hat Enterprise Linux.
+	OSPrettyName string `json:"os_pretty_name"`
+	// OSVersion is the major version of the distribution, e.g. 8.
+	OSVersion string `json:"os_version"`
+	// OSCodename is the release name of the major version, e.g. Ootpa.
+	OSCodename string `json:"os_codename"`
+}
+
+// VersionPattern matches the full distribution version of images built on
+// the distro, e.g. 8.10.
+func (d Distro) VersionPattern() string {
+	return fmt.Sprintf(`^%s\.\d+$`, regexp.QuoteMeta(d.OSVersion))
+}
+
+// PrettyNamePattern matches the PRETTY_NAME line of the /etc/os-release of
+// images built on the distro.
+func (d Distro) PrettyNamePattern() string {
+	return fmt.Sprintf(`PRETTY_NAME="%s %s\.\d+ \(%s\)"`, regexp.QuoteMeta(d.OSPrettyName), regexp.QuoteMeta(d.OSVersion), regexp.QuoteMeta(d.OSCodename))
+}
+
+// Distro returns the distro the entry is built on.
+func (i ImagesJson) Distro(stack StackImages) (Distro, error) {
+	distro, ok := i.Distros[stack.Distro]
+	if !ok {
+		return Distro{}, fmt.Errorf("%s: unknown distro %q", stack.Name, stack.Distro)
+	}
+
+	return distro, nil
+}
+
+// DistroNames returns the distros used by at least one entry, sorted by name.
+func (i ImagesJson) DistroNames() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, stack := range i.StackImages {
+		if !seen[stack.Distro] {
+			seen[stack.Distro] = true
+			names = append(names, stack.Distro)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,76 @@
+package images_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testDistro(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		distro images.Distro
+	)
+
+	it.Before(func() {
+		distro = images.Distro{
+			StackID:      "io.buildpacks.stacks.ubi8",
+			OSName:       "rhel",
+			OSPrettyName: "Red Hat Enterprise Linux",
+			OSVersion:    "8",
+			OSCodename:   "Ootpa",
+		}
+	})
+
+	context("VersionPattern", func() {
+		it("matches the minor versions of the distro only", func() {
+			pattern := regexp.MustCompile(distro.VersionPattern())
+			Expect(pattern.MatchString("8.10")).To(BeTrue())
+			Expect(pattern.MatchString("9.4")).To(BeFalse())
+			Expect(pattern.MatchString("18.1")).To(BeFalse())
+		})
+	})
+
+	context("PrettyNamePattern", func() {
+		it("matches the PRETTY_NAME of the distro", func() {
+			pattern := regexp.MustCompile(distro.PrettyNamePattern())
+			Expect(pattern.MatchString(`PRETTY_NAME="Red Hat Enterprise Linux 8.10 (Ootpa)"`)).To(BeTrue())
+			Expect(pattern.MatchString(`PRETTY_NAME="Red Hat Enterprise Linux 9.4 (Plow)"`)).To(BeFalse())
+		})
+	})
+
+	context("DistroNames", func() {
+		it("lists the distros in use once, sorted", func() {
+			imagesJson := images.ImagesJson{
+				Distros: map[string]images.Distro{"ubi8": distro, "ubi9": {}, "ubi10": {}},
+				StackImages: []images.StackImages{
+					{Name: "ubi9", Distro: "ubi9"},
+					{Name: "default", Distro: "ubi8"},
+					{Name: "nodejs-20", Distro: "ubi8"},
+				},
+			}
+
+			Expect(imagesJson.DistroNames()).To(Equal([]string{"ubi8", "ubi9"}))
+		})
+	})
+
+	context("Distro", func() {
+		it("returns the distro of an entry", func() {
+			imagesJson := images.ImagesJson{Distros: map[string]images.Distro{"ubi8": distro}}
+
+			Expect(imagesJson.Distro(images.StackImages{Name: "default", Distro: "ubi8"})).To(Equal(distro))
+		})
+
+		context("failure cases", func() {
+			it("fails for undeclared distros", func() {
+				_, err := images.ImagesJson{}.Distro(images.StackImages{Name: "default", Distro: "ubi9"})
+				Expect(err).To(MatchError(`default: unknown distro "ubi9"`))
+			})
+		})
+	})
+}
@@ -0,0 +1,451 @@
+package images
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Document is an images descriptor opened for editing. Key order and the
+// encoding of untouched values are kept, so writing it back only changes the
+// lines that were edited.
+type Document struct {
+	top     object
+	entries []object
+}
+
+type object []member
+
+type member struct {
+	key   string
+	value json.RawMessage
+}
+
+// OpenDocument reads the images descriptor at path for editing.
+func OpenDocument(path string) (*Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := ParseDocument(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return document, nil
+}
+
+// ParseDocument decodes an images descriptor for editing. The content must
+// also be a valid ImagesJson.
+func ParseDocument(content []byte) (*Document, error) {
+	_, err := Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	var document Document
+	document.top, err = decodeObject(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if raw, ok := document.top.get("images"); ok {
+		var entries []json.RawMessage
+		err = json.Unmarshal(raw, &entries)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			o, err := decodeObject(entry)
+			if err != nil {
+				return nil, err
+			}
+			document.entries = append(document.entries, o)
+		}
+	}
+
+	return &document, nil
+}
+
+// ImagesJson decodes the edited document.
+func (d *Document) ImagesJson() (ImagesJson, error) {
+	content, err := d.Bytes()
+	if err != nil {
+		return ImagesJson{}, err
+	}
+
+	return Parse(bytes.NewReader(content))
+}
+
+// Set assigns a top-level field such as receipts_show_limit.
+func (d *Document) Set(key string, value any) error {
+	if key == "images" {
+		return fmt.Errorf("images cannot be set directly, edit its entries instead")
+	}
+
+	raw, err := encode(value)
+	if err != nil {
+		return err
+	}
+
+	return d.apply(d.top.set(key, raw, jsonKeys(ImagesJson{})), d.entries)
+}
+
+// Add inserts entry at index, or appends it when index is negative.
+func (d *Document) Add(entry StackImages, index int) error {
+	if d.index(entry.Name) >= 0 {
+		return fmt.Errorf("an entry named %q already exists", entry.Name)
+	}
+
+	content, err := encode(entry)
+	if err != nil {
+		return err
+	}
+
+	o, err := decodeObject(content)
+	if err != nil {
+		return err
+	}
+
+	if index < 0 || index > len(d.entries) {
+		index = len(d.entries)
+	}
+
+	return d.apply(d.top, slices.Insert(slices.Clone(d.entries), index, o))
+}
+
+// Update assigns a field of the named entry. Setting a field to its zero value
+// removes it when the model omits empty values.
+func (d *Document) Update(name string, key string, value any) error {
+	index := d.index(name)
+	if index < 0 {
+		return fmt.Errorf("no entry named %q", name)
+	}
+
+	if rename, ok := value.(string); key == "name" && ok && rename != name && d.index(rename) >= 0 {
+		return fmt.Errorf("an entry named %q already exists", rename)
+	}
+
+	raw, err := encode(value)
+	if err != nil {
+		return err
+	}
+
+	entries := slices.Clone(d.entries)
+	if omitted(StackImages{}, key, raw) {
+		entries[index] = entries[index].remove(key)
+	} else {
+		entries[index] = entries[index].set(key, raw, jsonKeys(StackImages{}))
+	}
+
+	return d.apply(d.top, entries)
+}
+
+// Remove deletes the named entry.
+func (d *Document) Remove(name string) error {
+	index := d.index(name)
+	if index < 0 {
+		return fmt.Errorf("no entry named %q", name)
+	}
+
+	return d.apply(d.top, slices.Delete(slices.Clone(d.entries), index, index+1))
+}
+
+// Move places the named entry at index.
+func (d *Document) Move(name string, index int) error {
+	from := d.index(name)
+	if from < 0 {
+		return fmt.Errorf("no entry named %q", name)
+	}
+
+	if index < 0 || index >= len(d.entries) {
+		return fmt.Errorf("index %d is out of range [0, %d)", index, len(d.entries))
+	}
+
+	entry := d.entries[from]
+	entries := slices.Delete(slices.Clone(d.entries), from, from+1)
+
+	return d.apply(d.top, slices.Insert(entries, index, entry))
+}
+
+// SetDefaultRunImage marks the named entry as the default run image of its
+// distro and clears the flag on every other entry of that distro.
+func (d *Document) SetDefaultRunImage(name string) error {
+	imagesJson, err := d.ImagesJson()
+	if err != nil {
+		return err
+	}
+
+	stack, err := imagesJson.Lookup(name)
+	if err != nil {
+		return fmt.Errorf("no entry named %q", name)
+	}
+
+	for _, entry := range imagesJson.StackImages {
+		if entry.Distro != stack.Distro {
+			continue
+		}
+
+		err := d.Update(entry.Name, "is_default_run_image", entry.Name == name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Bytes encodes the document with the two-space indentation used by the
+// repository.
+func (d *Document) Bytes() ([]byte, error) {
+	var entries []json.RawMessage
+	for _, entry := range d.entries {
+		entries = append(entries, entry.marshal())
+	}
+
+	top := d.top
+	if _, ok := top.get("images"); ok || len(entries) > 0 {
+		raw, err := json.Marshal(entries)
+		if err != nil {
+			return nil, err
+		}
+		top = top.set("images", raw, jsonKeys(ImagesJson{}))
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	err := json.Indent(buffer, top.marshal(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	buffer.WriteByte('\n')
+
+	return buffer.Bytes(), nil
+}
+
+// Write stores the document at path.
+func (d *Document) Write(path string) error {
+	content, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0644)
+}
+
+// apply replaces the contents of the document unless the edit would make it
+// fail to decode.
+func (d *Document) apply(top object, entries []object) error {
+	edited := &Document{top: top, entries: entries}
+	_, err := edited.ImagesJson()
+	if err != nil {
+		return err
+	}
+
+	*d = *edited
+	return nil
+}
+
+func (d *Document) index(name string) int {
+	for i, entry := range d.entries {
+		raw, ok := entry.get("name")
+		if !ok {
+			continue
+		}
+
+		var entryName string
+		if json.Unmarshal(raw, &entryName) == nil && entryName == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func decodeObject(content []byte) (object, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token != json.Delim('{') {
+		return nil, fmt.Errorf("expected an object")
+	}
+
+	var o object
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		var value json.RawMessage
+		err = decoder.Decode(&value)
+		if err != nil {
+			return nil, err
+		}
+
+		o = append(o, member{key: token.(string), value: value})
+	}
+
+	return o, nil
+}
+
+func (o object) get(key string) (json.RawMessage, bool) {
+	for _, m := range o {
+		if m.key == key {
+			return m.value, true
+		}
+	}
+
+	return nil, false
+}
+
+// set replaces the value of key in place. A new key is inserted after the
+// closest preceding key in order, which lists the keys in model order.
+func (o object) set(key string, value json.RawMessage, order []string) object {
+	for i, m := range o {
+		if m.key == key {
+			o = slices.Clone(o)
+			o[i].value = value
+			return o
+		}
+	}
+
+	position := len(o)
+	if rank := slices.Index(order, key); rank >= 0 {
+		position = 0
+		for i, m := range o {
+			if other := slices.Index(order, m.key); other >= 0 && other < rank {
+				position = i + 1
+			}
+		}
+	}
+
+	return slices.Insert(slices.Clone(o), position, member{key: key, value: value})
+}
+
+func (o object) remove(key string) object {
+	return slices.DeleteFunc(slices.Clone(o), func(m member) bool { return m.key == key })
+}
+
+func (o object) marshal() []byte {
+	buffer := bytes.NewBufferString("{")
+	for i, m := range o {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		key, _ := json.Marshal(m.key)
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(m.value)
+	}
+	buffer.WriteByte('}')
+
+	return buffer.Bytes()
+}
+
+// ParseEntry decodes the JSON object of an entry to add, rejecting keys the
+// model does not know so that typos are not silently dropped.
+func ParseEntry(content []byte) (StackImages, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+
+	var entry StackImages
+	err := decoder.Decode(&entry)
+	if err != nil {
+		return StackImages{}, err
+	}
+
+	return entry, nil
+}
+
+// EntryValue converts the text given for a field of an entry to the type of
+// the field, see fieldValue.
+func EntryValue(key string, text string) (any, error) {
+	return fieldValue(StackImages{}, key, text)
+}
+
+// TopLevelValue converts the text given for a top-level field to the type of
+// the field, see fieldValue.
+func TopLevelValue(key string, text string) (any, error) {
+	return fieldValue(ImagesJson{}, key, text)
+}
+
+// fieldValue keeps text verbatim when it is JSON the key of the model of v
+// accepts, so objects keep the key order they were given in, and otherwise
+// takes it as a string, e.g. a runtime_version of 18. Unknown keys and text
+// that fits the field neither way fail.
+func fieldValue(v any, key string, text string) (any, error) {
+	var candidates []any
+	if json.Valid([]byte(text)) {
+		candidates = append(candidates, json.RawMessage(text))
+	}
+	candidates = append(candidates, text)
+
+	var err error
+	for _, candidate := range candidates {
+		var raw json.RawMessage
+		raw, err = encode(candidate)
+		if err != nil {
+			return nil, err
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(object{{key: key, value: raw}}.marshal()))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(reflect.New(reflect.TypeOf(v)).Interface())
+		if err == nil {
+			return candidate, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid value %q for %s: %w", text, key, err)
+}
+
+func encode(value any) (json.RawMessage, error) {
+	buffer := bytes.NewBuffer(nil)
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+
+	err := encoder.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSpace(buffer.Bytes()), nil
+}
+
+// jsonKeys lists the json keys of the fields of v in declaration order.
+func jsonKeys(v any) []string {
+	var keys []string
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		keys = append(keys, name)
+	}
+
+	return keys
+}
+
+// omitted reports whether the model of v would leave key out when it holds
+// value.
+func omitted(v any, key string, value json.RawMessage) bool {
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		name, options, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != key || !strings.Contains(options, "omitempty") {
+			continue
+		}
+
+		zero, err := encode(reflect.Zero(t.Field(i).Type).Interface())
+		return err == nil && bytes.Equal(zero, value)
+	}
+
+	return false
+}
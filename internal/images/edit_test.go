@@ -0,0 +1,219 @@
+package images_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testEdit(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		document *images.Document
+	)
+
+	const content = `{
+  "support_usns": false,
+  "update_on_new_image": true,
+  "receipts_show_limit": 16,
+  "distros": {
+    "ubi8": {
+      "stack_id": "io.buildpacks.stacks.ubi8",
+      "os_name": "rhel",
+      "os_pretty_name": "Red Hat Enterprise Linux",
+      "os_version": "8",
+      "os_codename": "Ootpa"
+    }
+  },
+  "images": [
+    {
+      "name": "default",
+      "config_dir": "stacks/stack",
+      "output_dir": "builds/build",
+      "build_image": "build",
+      "run_image": "run",
+      "build_receipt_filename": "build-receipt.cyclonedx.json",
+      "run_receipt_filename": "run-receipt.cyclonedx.json",
+      "create_build_image": true,
+      "base_run_container_image": "docker://registry.access.redhat.com/ubi8/ubi-minimal",
+      "type": "base",
+      "distro": "ubi8"
+    },
+    {
+      "name": "nodejs-20",
+      "is_default_run_image": true,
+      "config_dir": "stacks/stack-nodejs-20",
+      "output_dir": "builds/build-nodejs-20",
+      "build_image": "build-nodejs-20",
+      "run_image": "run-nodejs-20",
+      "build_receipt_filename": "build-nodejs-20-receipt.cyclonedx.json",
+      "run_receipt_filename": "run-nodejs-20-receipt.cyclonedx.json",
+      "base_run_container_image": "docker://registry.access.redhat.com/ubi8/nodejs-20-minimal",
+      "type": "nodejs",
+      "runtime_version": "20",
+      "distro": "ubi8"
+    }
+  ]
+}
+`
+
+	it.Before(func() {
+		var err error
+		document, err = images.ParseDocument([]byte(content))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	names := func() []string {
+		imagesJson, err := document.ImagesJson()
+		Expect(err).NotTo(HaveOccurred())
+
+		var names []string
+		for _, stack := range imagesJson.StackImages {
+			names = append(names, stack.Name)
+		}
+		return names
+	}
+
+	it("writes an unedited document back unchanged", func() {
+		Expect(document.Bytes()).To(Equal([]byte(content)))
+	})
+
+	it("sets top-level fields in place", func() {
+		Expect(document.Set("receipts_show_limit", 20)).To(Succeed())
+
+		Expect(document.Bytes()).To(Equal([]byte(replace(content, `"receipts_show_limit": 16`, `"receipts_show_limit": 20`))))
+	})
+
+	it("adds entries with keys in model order", func() {
+		Expect(document.Add(images.StackImages{
+			Name:                  "nodejs-22",
+			ConfigDir:             "stacks/stack-nodejs-22",
+			OutputDir:             "builds/build-nodejs-22",
+			BuildImage:            "build-nodejs-22",
+			RunImage:              "run-nodejs-22",
+			BuildReceiptFilename:  "build-nodejs-22-receipt.cyclonedx.json",
+			RunReceiptFilename:    "run-nodejs-22-receipt.cyclonedx.json",
+			BaseRunContainerImage: "docker://registry.access.redhat.com/ubi8/nodejs-22-minimal",
+			Type:                  images.TypeNodejs,
+			RuntimeVersion:        "22",
+			Distro:                "ubi8",
+		}, 1)).To(Succeed())
+
+		Expect(names()).To(Equal([]string{"default", "nodejs-22", "nodejs-20"}))
+		Expect(string(must(document.Bytes()))).To(ContainSubstring(`    {
+      "name": "nodejs-22",
+      "config_dir": "stacks/stack-nodejs-22",
+      "output_dir": "builds/build-nodejs-22",
+      "build_image": "build-nodejs-22",
+      "run_image": "run-nodejs-22",
+      "build_receipt_filename": "build-nodejs-22-receipt.cyclonedx.json",
+      "run_receipt_filename": "run-nodejs-22-receipt.cyclonedx.json",
+      "base_run_container_image": "docker://registry.access.redhat.com/ubi8/nodejs-22-minimal",
+      "type": "nodejs",
+      "runtime_version": "22",
+      "distro": "ubi8"
+    },
+    {
+      "name": "nodejs-20",`))
+	})
+
+	it("updates, removes and reorders entries", func() {
+		Expect(document.Update("nodejs-20", "base_run_container_image", "docker://registry.access.redhat.com/ubi9/nodejs-20-minimal")).To(Succeed())
+		Expect(document.Bytes()).To(Equal([]byte(replace(content, "ubi8/nodejs-20-minimal", "ubi9/nodejs-20-minimal"))))
+
+		Expect(document.Move("nodejs-20", 0)).To(Succeed())
+		Expect(names()).To(Equal([]string{"nodejs-20", "default"}))
+
+		Expect(document.Remove("nodejs-20")).To(Succeed())
+		Expect(names()).To(Equal([]string{"default"}))
+	})
+
+	it("moves the default run image flag", func() {
+		Expect(document.SetDefaultRunImage("default")).To(Succeed())
+
+		imagesJson, err := document.ImagesJson()
+		Expect(err).NotTo(HaveOccurred())
+
+		defaultRunStack, err := imagesJson.DefaultRunStack("ubi8")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(defaultRunStack.Name).To(Equal("default"))
+
+		edited := string(must(document.Bytes()))
+		Expect(edited).To(ContainSubstring(`      "name": "default",
+      "is_default_run_image": true,
+      "config_dir": "stacks/stack",`))
+		Expect(edited).To(ContainSubstring(`      "name": "nodejs-20",
+      "config_dir": "stacks/stack-nodejs-20",`))
+	})
+
+	context("ParseEntry", func() {
+		it("decodes the entry", func() {
+			entry, err := images.ParseEntry([]byte(`{"name": "nodejs-22", "runtime_version": "22", "is_default_run_image": true}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entry).To(Equal(images.StackImages{Name: "nodejs-22", RuntimeVersion: "22", IsDefaultRunImage: true}))
+		})
+
+		it("rejects unknown keys", func() {
+			_, err := images.ParseEntry([]byte(`{"name": "nodejs-22", "is_default_run_imag": true}`))
+			Expect(err).To(MatchError(ContainSubstring(`unknown field "is_default_run_imag"`)))
+		})
+	})
+
+	context("EntryValue and TopLevelValue", func() {
+		it("converts the text to the type of the field", func() {
+			Expect(images.EntryValue("runtime_version", "18")).To(Equal("18"))
+			Expect(images.EntryValue("base_run_container_image", "docker://registry.access.redhat.com/ubi8/nodejs-18-minimal")).To(Equal("docker://registry.access.redhat.com/ubi8/nodejs-18-minimal"))
+			Expect(images.EntryValue("create_build_image", "true")).To(Equal(json.RawMessage("true")))
+			Expect(images.EntryValue("lifecycle", `{"deprecated": true}`)).To(Equal(json.RawMessage(`{"deprecated": true}`)))
+			Expect(images.TopLevelValue("receipts_show_limit", "20")).To(Equal(json.RawMessage("20")))
+
+			value, err := images.EntryValue("runtime_version", "18")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(document.Update("nodejs-20", "runtime_version", value)).To(Succeed())
+			Expect(string(must(document.Bytes()))).To(ContainSubstring(`"runtime_version": "18"`))
+		})
+
+		it("rejects unknown keys and values of another type", func() {
+			_, err := images.EntryValue("is_default_run_imag", "true")
+			Expect(err).To(MatchError(ContainSubstring(`unknown field "is_default_run_imag"`)))
+
+			_, err = images.EntryValue("create_build_image", "yes")
+			Expect(err).To(MatchError(ContainSubstring(`invalid value "yes" for create_build_image`)))
+
+			_, err = images.TopLevelValue("receipts_show_limit", "many")
+			Expect(err).To(MatchError(ContainSubstring("cannot unmarshal string")))
+		})
+	})
+
+	context("failure cases", func() {
+		it("rejects edits that leave the model", func() {
+			Expect(document.Set("receipts_show_limit", "many")).To(MatchError(ContainSubstring("cannot unmarshal string")))
+			Expect(document.Update("nodejs-20", "unknown", true)).To(MatchError(ContainSubstring(`unknown field "unknown"`)))
+			Expect(document.Bytes()).To(Equal([]byte(content)))
+		})
+
+		it("rejects unknown and duplicate entries", func() {
+			Expect(document.Remove("nodejs-16")).To(MatchError(`no entry named "nodejs-16"`))
+			Expect(document.Move("nodejs-20", 2)).To(MatchError("index 2 is out of range [0, 2)"))
+			Expect(document.Add(images.StackImages{Name: "default"}, -1)).To(MatchError(`an entry named "default" already exists`))
+			Expect(document.Update("nodejs-20", "name", "default")).To(MatchError(`an entry named "default" already exists`))
+		})
+	})
+}
+
+func replace(content, old, updated string) string {
+	return strings.Replace(content, old, updated, 1)
+}
+
+func must(content []byte, err error) []byte {
+	if err != nil {
+		panic(err)
+	}
+	return content
+}
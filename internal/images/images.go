@@ -0,0 +1,307 @@
+package images
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Filename is the location of the images descriptor relative to the
+// repository root.
+const Filename = "stacks/images.json"
+
+// Types of images.json entries. The type of a variant decides which
+// integration suites run against it.
+const (
+	TypeBase   = "base"
+	TypeJava   = "java"
+	TypeNodejs = "nodejs"
+)
+
+type StackImages struct {
+	Name                    string     `json:"name"`
+	IsDefaultRunImage       bool       `json:"is_default_run_image,omitempty"`
+	ConfigDir               string     `json:"config_dir"`
+	OutputDir               string     `json:"output_dir"`
+	BuildImage              string     `json:"build_image"`
+	RunImage                string     `json:"run_image"`
+	BuildReceiptFilename    string     `json:"build_receipt_filename"`
+	RunReceiptFilename      string     `json:"run_receipt_filename"`
+	CreateBuildImage        bool       `json:"create_build_image,omitempty"`
+	BaseBuildContainerImage string     `json:"base_build_container_image,omitempty"`
+	BaseRunContainerImage   string     `json:"base_run_container_image"`
+	Type                    string     `json:"type"`
+	RuntimeVersion          string     `json:"runtime_version,omitempty"`
+	Lifecycle               *Lifecycle `json:"lifecycle,omitempty"`
+	Distro                  string     `json:"distro"`
+}
+
+type ImagesJson struct {
+	SupportUsns       bool              `json:"support_usns"`
+	UpdateOnNewImage  bool              `json:"update_on_new_image"`
+	ReceiptsShowLimit int               `json:"receipts_show_limit"`
+	Distros           map[string]Distro `json:"distros"`
+	StackImages       []StackImages     `json:"images"`
+}
+
+// Load reads the images descriptor of the repository at root and validates
+// it against the repository contents.
+func Load(root string) (ImagesJson, error) {
+	imagesJson, err := LoadFile(filepath.Join(root, Filename))
+	if err != nil {
+		return ImagesJson{}, err
+	}
+
+	err = imagesJson.Validate(root)
+	if err != nil {
+		return ImagesJson{}, err
+	}
+
+	return imagesJson, nil
+}
+
+// LoadFile parses the images descriptor at path without checking it against
+// the filesystem.
+func LoadFile(path string) (ImagesJson, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ImagesJson{}, err
+	}
+	defer file.Close()
+
+	imagesJson, err := Parse(file)
+	if err != nil {
+		return ImagesJson{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return imagesJson, nil
+}
+
+// Parse decodes an images descriptor, rejecting any key that is not part of
+// the model.
+func Parse(r io.Reader) (ImagesJson, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return ImagesJson{}, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+
+	var imagesJson ImagesJson
+	err = decoder.Decode(&imagesJson)
+	if err != nil {
+		return ImagesJson{}, err
+	}
+
+	if decoder.More() {
+		return ImagesJson{}, errors.New("unexpected content after the top-level object")
+	}
+
+	return imagesJson, nil
+}
+
+// Validate checks the invariants the build and test tooling rely on. All
+// violations are reported together.
+func (i ImagesJson) Validate(root string) error {
+	var errs []error
+
+	names := map[string]bool{}
+	outputDirs := map[string]string{}
+	buildImageProviders := map[string][]string{}
+	defaultRunImages := map[string][]string{}
+	var replacements []StackImages
+
+	for index, stack := range i.StackImages {
+		if stack.Name == "" {
+			errs = append(errs, fmt.Errorf("images[%d]: name must not be empty", index))
+		} else if names[stack.Name] {
+			errs = append(errs, fmt.Errorf("images[%d]: duplicate name %q", index, stack.Name))
+		}
+		names[stack.Name] = true
+
+		if stack.ConfigDir == "" {
+			errs = append(errs, fmt.Errorf("images[%d] %q: config_dir must not be empty", index, stack.Name))
+		} else {
+			info, err := os.Stat(filepath.Join(root, stack.ConfigDir))
+			switch {
+			case err != nil:
+				errs = append(errs, fmt.Errorf("images[%d] %q: config_dir %q does not exist", index, stack.Name, stack.ConfigDir))
+			case !info.IsDir():
+				errs = append(errs, fmt.Errorf("images[%d] %q: config_dir %q is not a directory", index, stack.Name, stack.ConfigDir))
+			}
+		}
+
+		if _, ok := i.Distros[stack.Distro]; !ok {
+			errs = append(errs, fmt.Errorf("images[%d] %q: distro %q is not declared in distros", index, stack.Name, stack.Distro))
+		}
+
+		switch {
+		case stack.Type == "":
+			errs = append(errs, fmt.Errorf("images[%d] %q: type must not be empty", index, stack.Name))
+		case stack.Type == TypeBase && stack.RuntimeVersion != "":
+			errs = append(errs, fmt.Errorf("images[%d] %q: runtime_version cannot be set for type %q", index, stack.Name, stack.Type))
+		case stack.Type != TypeBase && stack.RuntimeVersion == "":
+			errs = append(errs, fmt.Errorf("images[%d] %q: runtime_version must be set for type %q", index, stack.Name, stack.Type))
+		}
+
+		if stack.OutputDir == "" {
+			errs = append(errs, fmt.Errorf("images[%d] %q: output_dir must not be empty", index, stack.Name))
+		} else {
+			outputDir := filepath.Clean(stack.OutputDir)
+			if other, ok := outputDirs[outputDir]; ok {
+				errs = append(errs, fmt.Errorf("images[%d] %q: output_dir %q collides with %q", index, stack.Name, stack.OutputDir, other))
+			}
+			outputDirs[outputDir] = stack.Name
+		}
+
+		if stack.Lifecycle != nil {
+			if stack.Lifecycle.SupportedUntil != "" {
+				_, err := time.Parse(DateLayout, stack.Lifecycle.SupportedUntil)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("images[%d] %q: lifecycle.supported_until %q is not a %s date", index, stack.Name, stack.Lifecycle.SupportedUntil, DateLayout))
+				}
+			}
+
+			if stack.Lifecycle.Replacement != "" {
+				replacements = append(replacements, stack)
+			}
+		}
+
+		if stack.CreateBuildImage {
+			buildImageProviders[stack.Distro] = append(buildImageProviders[stack.Distro], stack.Name)
+		}
+
+		if stack.IsDefaultRunImage {
+			defaultRunImages[stack.Distro] = append(defaultRunImages[stack.Distro], stack.Name)
+		}
+	}
+
+	for _, stack := range replacements {
+		if stack.Lifecycle.Replacement == stack.Name || !names[stack.Lifecycle.Replacement] {
+			errs = append(errs, fmt.Errorf("%s: lifecycle.replacement %q is not another entry", stack.Name, stack.Lifecycle.Replacement))
+		}
+	}
+
+	distros := i.DistroNames()
+	if len(distros) == 0 {
+		errs = append(errs, errors.New("at least one image must set create_build_image and is_default_run_image"))
+	}
+
+	for _, distro := range distros {
+		if providers := buildImageProviders[distro]; len(providers) != 1 {
+			errs = append(errs, fmt.Errorf("distro %q: exactly one image must set create_build_image, found %d %q", distro, len(providers), providers))
+		}
+
+		if runImages := defaultRunImages[distro]; len(runImages) != 1 {
+			errs = append(errs, fmt.Errorf("distro %q: exactly one image must set is_default_run_image, found %d %q", distro, len(runImages), runImages))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// BuildStack returns the entry that provides the build image shared by every
+// variant of distro.
+func (i ImagesJson) BuildStack(distro string) (StackImages, error) {
+	return i.single(distro, "create_build_image", func(stack StackImages) bool { return stack.CreateBuildImage })
+}
+
+// DefaultRunStack returns the entry whose run image is paired with the build
+// image of distro by default.
+func (i ImagesJson) DefaultRunStack(distro string) (StackImages, error) {
+	return i.single(distro, "is_default_run_image", func(stack StackImages) bool { return stack.IsDefaultRunImage })
+}
+
+// WithBuilderStacks adds to selected the build and default run stacks of
+// their distros, which every builder of the distro is made of, and returns
+// the entries in images.json order.
+func (i ImagesJson) WithBuilderStacks(selected []StackImages) ([]StackImages, error) {
+	names := map[string]bool{}
+	for _, stack := range selected {
+		names[stack.Name] = true
+
+		buildStack, err := i.BuildStack(stack.Distro)
+		if err != nil {
+			return nil, err
+		}
+		names[buildStack.Name] = true
+
+		runStack, err := i.DefaultRunStack(stack.Distro)
+		if err != nil {
+			return nil, err
+		}
+		names[runStack.Name] = true
+	}
+
+	var stacks []StackImages
+	for _, stack := range i.StackImages {
+		if names[stack.Name] {
+			stacks = append(stacks, stack)
+		}
+	}
+
+	return stacks, nil
+}
+
+// Lookup returns the entry with the given name.
+func (i ImagesJson) Lookup(name string) (StackImages, error) {
+	for _, stack := range i.StackImages {
+		if stack.Name == name {
+			return stack, nil
+		}
+	}
+
+	return StackImages{}, fmt.Errorf("no image is named %q", name)
+}
+
+// LookupImage returns the entry that produces the build or run image with the
+// given name.
+func (i ImagesJson) LookupImage(image string) (StackImages, error) {
+	for _, stack := range i.StackImages {
+		if stack.RunImage == image || (stack.BuildImage == image && stack.CreateBuildImage) {
+			return stack, nil
+		}
+	}
+
+	return StackImages{}, fmt.Errorf("no image produces %q", image)
+}
+
+func (i ImagesJson) single(distro string, flag string, match func(StackImages) bool) (StackImages, error) {
+	var matches []StackImages
+	for _, stack := range i.StackImages {
+		if stack.Distro == distro && match(stack) {
+			matches = append(matches, stack)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return StackImages{}, fmt.Errorf("no %s image sets %s", distro, flag)
+	case 1:
+		return matches[0], nil
+	default:
+		var names []string
+		for _, stack := range matches {
+			names = append(names, stack.Name)
+		}
+		return StackImages{}, fmt.Errorf("several %s images set %s: %q", distro, flag, names)
+	}
+}
+
+// BuildArchive returns the path of the build image OCI archive produced for
+// the entry.
+func (s StackImages) BuildArchive(root string) string {
+	return filepath.Join(root, s.OutputDir, "build.oci")
+}
+
+// RunArchive returns the path of the run image OCI archive produced for the
+// entry.
+func (s StackImages) RunArchive(root string) string {
+	return filepath.Join(root, s.OutputDir, "run.oci")
+}
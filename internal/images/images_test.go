@@ -0,0 +1,195 @@
+package images_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testImages(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		root string
+	)
+
+	it.Before(func() {
+		var err error
+		root, err = os.MkdirTemp("", "root")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(root, "stacks", "stack"), os.ModePerm)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(root, "stacks", "stack-nodejs-20"), os.ModePerm)).To(Succeed())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	writeImagesJson := func(content string) {
+		Expect(os.WriteFile(filepath.Join(root, images.Filename), []byte(content), 0644)).To(Succeed())
+	}
+
+	context("Load", func() {
+		it("loads a valid descriptor", func() {
+			writeImagesJson(`{
+  "support_usns": false,
+  "update_on_new_image": true,
+  "receipts_show_limit": 16,
+  "distros": {
+    "ubi8": {"stack_id": "io.buildpacks.stacks.ubi8", "os_name": "rhel", "os_pretty_name": "Red Hat Enterprise Linux", "os_version": "8", "os_codename": "Ootpa"}
+  },
+  "images": [
+    {
+      "name": "default",
+      "config_dir": "stacks/stack",
+      "output_dir": "builds/build",
+      "build_image": "build",
+      "run_image": "run",
+      "create_build_image": true,
+      "base_run_container_image": "docker://registry.access.redhat.com/ubi8/ubi-minimal",
+      "type": "base",
+      "distro": "ubi8"
+    },
+    {
+      "name": "nodejs-20",
+      "is_default_run_image": true,
+      "config_dir": "stacks/stack-nodejs-20",
+      "output_dir": "builds/build-nodejs-20",
+      "build_image": "build-nodejs-20",
+      "run_image": "run-nodejs-20",
+      "base_run_container_image": "docker://registry.access.redhat.com/ubi8/nodejs-20-minimal",
+      "type": "nodejs",
+      "runtime_version": "20",
+      "distro": "ubi8"
+    }
+  ]
+}`)
+
+			imagesJson, err := images.Load(root)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(imagesJson.UpdateOnNewImage).To(BeTrue())
+			Expect(imagesJson.ReceiptsShowLimit).To(Equal(16))
+			Expect(imagesJson.StackImages).To(HaveLen(2))
+			Expect(imagesJson.StackImages[0].CreateBuildImage).To(BeTrue())
+			Expect(imagesJson.StackImages[1].IsDefaultRunImage).To(BeTrue())
+			Expect(imagesJson.StackImages[1].Type).To(Equal(images.TypeNodejs))
+			Expect(imagesJson.StackImages[1].RuntimeVersion).To(Equal("20"))
+		})
+
+		context("failure cases", func() {
+			it("rejects unknown keys", func() {
+				writeImagesJson(`{"images": [{"name": "default", "confg_dir": "stacks/stack"}]}`)
+
+				_, err := images.Load(root)
+				Expect(err).To(MatchError(ContainSubstring(`unknown field "confg_dir"`)))
+			})
+
+			it("rejects trailing content", func() {
+				_, err := images.Parse(strings.NewReader(`{"images": []} {}`))
+				Expect(err).To(MatchError("unexpected content after the top-level object"))
+			})
+
+			it("reports every invariant violation", func() {
+				writeImagesJson(`{
+  "distros": {"ubi8": {"stack_id": "io.buildpacks.stacks.ubi8"}},
+  "images": [
+    {"name": "default", "config_dir": "stacks/stack", "output_dir": "builds/build", "create_build_image": true, "type": "base", "runtime_version": "8", "distro": "ubi8"},
+    {"name": "default", "config_dir": "stacks/missing", "output_dir": "builds/build/", "create_build_image": true, "type": "nodejs", "distro": "ubi8"},
+    {"name": "java-8", "config_dir": "stacks/stack", "output_dir": "builds/build-java-8", "lifecycle": {"supported_until": "30/11/2026", "replacement": "java-8"}, "distro": "ubi9"}
+  ]
+}`)
+
+				_, err := images.Load(root)
+				Expect(err).To(MatchError(SatisfyAll(
+					ContainSubstring(`images[1]: duplicate name "default"`),
+					ContainSubstring(`config_dir "stacks/missing" does not exist`),
+					ContainSubstring(`output_dir "builds/build/" collides with "default"`),
+					ContainSubstring(`images[0] "default": runtime_version cannot be set for type "base"`),
+					ContainSubstring(`images[1] "default": runtime_version must be set for type "nodejs"`),
+					ContainSubstring(`images[2] "java-8": type must not be empty`),
+					ContainSubstring(`images[2] "java-8": lifecycle.supported_until "30/11/2026" is not a 2006-01-02 date`),
+					ContainSubstring(`java-8: lifecycle.replacement "java-8" is not another entry`),
+					ContainSubstring(`images[2] "java-8": distro "ubi9" is not declared in distros`),
+					ContainSubstring(`distro "ubi8": exactly one image must set create_build_image, found 2`),
+					ContainSubstring(`distro "ubi8": exactly one image must set is_default_run_image, found 0`),
+					ContainSubstring(`distro "ubi9": exactly one image must set create_build_image, found 0`),
+				)))
+			})
+		})
+	})
+	context("BuildStack and DefaultRunStack", func() {
+		it("separates the build image provider from the default run image of each distro", func() {
+			imagesJson := images.ImagesJson{
+				StackImages: []images.StackImages{
+					{Name: "default", CreateBuildImage: true, Distro: "ubi8"},
+					{Name: "java-8", Distro: "ubi8"},
+					{Name: "nodejs-20", IsDefaultRunImage: true, Distro: "ubi8"},
+					{Name: "ubi9", CreateBuildImage: true, IsDefaultRunImage: true, Distro: "ubi9"},
+				},
+			}
+
+			buildStack, err := imagesJson.BuildStack("ubi8")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buildStack.Name).To(Equal("default"))
+
+			runStack, err := imagesJson.DefaultRunStack("ubi8")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runStack.Name).To(Equal("nodejs-20"))
+
+			buildStack, err = imagesJson.BuildStack("ubi9")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buildStack.Name).To(Equal("ubi9"))
+		})
+
+		context("failure cases", func() {
+			it("fails when no image of the distro claims the default run image", func() {
+				_, err := images.ImagesJson{
+					StackImages: []images.StackImages{
+						{Name: "default", Distro: "ubi8"},
+						{Name: "ubi9", IsDefaultRunImage: true, Distro: "ubi9"},
+					},
+				}.DefaultRunStack("ubi8")
+				Expect(err).To(MatchError("no ubi8 image sets is_default_run_image"))
+			})
+
+			it("fails when several images of the distro claim the default run image", func() {
+				_, err := images.ImagesJson{
+					StackImages: []images.StackImages{
+						{Name: "nodejs-18", IsDefaultRunImage: true, Distro: "ubi8"},
+						{Name: "nodejs-20", IsDefaultRunImage: true, Distro: "ubi8"},
+					},
+				}.DefaultRunStack("ubi8")
+				Expect(err).To(MatchError(`several ubi8 images set is_default_run_image: ["nodejs-18" "nodejs-20"]`))
+			})
+		})
+	})
+
+	context("WithBuilderStacks", func() {
+		it("adds the build and default run stacks of the selected distros in images.json order", func() {
+			imagesJson := images.ImagesJson{
+				StackImages: []images.StackImages{
+					{Name: "default", CreateBuildImage: true, Distro: "ubi8"},
+					{Name: "java-8", Distro: "ubi8"},
+					{Name: "nodejs-20", IsDefaultRunImage: true, Distro: "ubi8"},
+					{Name: "ubi9", CreateBuildImage: true, IsDefaultRunImage: true, Distro: "ubi9"},
+				},
+			}
+
+			stacks, err := imagesJson.WithBuilderStacks([]images.StackImages{imagesJson.StackImages[1]})
+			Expect(err).NotTo(HaveOccurred())
+
+			var names []string
+			for _, stack := range stacks {
+				names = append(names, stack.Name)
+			}
+			Expect(names).To(Equal([]string{"default", "java-8", "nodejs-20"}))
+		})
+	})
+}
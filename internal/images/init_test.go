@@ -0,0 +1,18 @@
+package images_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitImages(t *testing.T) {
+	suite := spec.New("images", spec.Report(report.Terminal{}))
+	suite("Images", testImages)
+	suite("Lifecycle", testLifecycle)
+	suite("Distro", testDistro)
+	suite("Selector", testSelector)
+	suite("Edit", testEdit)
+	suite.Run(t)
+}
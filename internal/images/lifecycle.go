@@ -0,0 +1,148 @@
+package images
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the format of lifecycle dates.
+const DateLayout = "2006-01-02"
+
+// PublishGracePeriod is how long after the end of its support a variant can
+// still be published.
+const PublishGracePeriod = 90 * 24 * time.Hour
+
+// Lifecycle records how long a variant is supported and what replaces it.
+type Lifecycle struct {
+	SupportedUntil string `json:"supported_until,omitempty"`
+	Deprecated     bool   `json:"deprecated,omitempty"`
+	Replacement    string `json:"replacement,omitempty"`
+}
+
+// Status is the position of a variant in its lifecycle at a given time.
+type Status int
+
+const (
+	// Supported variants are built and published as usual.
+	Supported Status = iota
+	// Deprecated variants are still supported but have been flagged for
+	// removal.
+	Deprecated
+	// Expired variants are past the end of their support but within the
+	// publish grace period.
+	Expired
+	// Retired variants are past the publish grace period and must not be
+	// published anymore.
+	Retired
+)
+
+func (s Status) String() string {
+	switch s {
+	case Deprecated:
+		return "deprecated"
+	case Expired:
+		return "expired"
+	case Retired:
+		return "retired"
+	default:
+		return "supported"
+	}
+}
+
+// Status reports where the entry is in its lifecycle at now.
+func (s StackImages) Status(now time.Time) Status {
+	if s.Lifecycle == nil {
+		return Supported
+	}
+
+	// validated by ImagesJson.Validate, an unparsable date is never
+	// considered expired
+	supportEnd, publishEnd, ok := s.lifecycleEnds()
+	if ok {
+		switch {
+		case !now.Before(publishEnd):
+			return Retired
+		case !now.Before(supportEnd):
+			return Expired
+		}
+	}
+
+	if s.Lifecycle.Deprecated {
+		return Deprecated
+	}
+
+	return Supported
+}
+
+// LifecycleWarning describes why building the entry at now deserves
+// attention, or returns an empty string for supported entries.
+func (s StackImages) LifecycleWarning(now time.Time) string {
+	status := s.Status(now)
+	if status == Supported {
+		return ""
+	}
+
+	warning := fmt.Sprintf("%s is %s", s.Name, status)
+	if status == Expired || status == Retired {
+		warning = fmt.Sprintf("%s, its support ended on %s", warning, s.Lifecycle.SupportedUntil)
+	}
+	if status == Expired {
+		warning = fmt.Sprintf("%s and it can be published until %s", warning, s.publishDeadline().Format(DateLayout))
+	}
+	if s.Lifecycle.Replacement != "" {
+		warning = fmt.Sprintf("%s, use %s instead", warning, s.Lifecycle.Replacement)
+	}
+
+	return warning
+}
+
+// CheckPublishable fails for entries that are past the publish grace period.
+func (s StackImages) CheckPublishable(now time.Time) error {
+	if s.Status(now) == Retired {
+		return fmt.Errorf("refusing to publish %s: its support ended on %s and it could be published until %s", s.Name, s.Lifecycle.SupportedUntil, s.publishDeadline().Format(DateLayout))
+	}
+
+	return nil
+}
+
+// MetadataLabel returns the value of the io.buildpacks.stack.metadata label
+// of the images built for the entry.
+func (s StackImages) MetadataLabel() (string, error) {
+	metadata := struct {
+		Lifecycle *Lifecycle `json:"lifecycle,omitempty"`
+	}{
+		Lifecycle: s.Lifecycle,
+	}
+
+	content, err := json.Marshal(metadata)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+// lifecycleEnds returns when the support of the entry ends, the day after its
+// supported_until date, and when it can no longer be published. ok is false
+// for entries without a valid supported_until date.
+func (s StackImages) lifecycleEnds() (supportEnd, publishEnd time.Time, ok bool) {
+	if s.Lifecycle == nil || s.Lifecycle.SupportedUntil == "" {
+		return time.Time{}, time.Time{}, false
+	}
+
+	until, err := time.Parse(DateLayout, s.Lifecycle.SupportedUntil)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	supportEnd = until.Add(24 * time.Hour)
+	return supportEnd, supportEnd.Add(PublishGracePeriod), true
+}
+
+// publishDeadline is the last day the entry can be published. Only valid for
+// entries with a supported_until date.
+func (s StackImages) publishDeadline() time.Time {
+	_, publishEnd, _ := s.lifecycleEnds()
+	return publishEnd.Add(-24 * time.Hour)
+}
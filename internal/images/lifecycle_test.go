@@ -0,0 +1,101 @@
+package images_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testLifecycle(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		stack images.StackImages
+	)
+
+	date := func(value string) time.Time {
+		parsed, err := time.Parse(images.DateLayout, value)
+		Expect(err).NotTo(HaveOccurred())
+		return parsed
+	}
+
+	it.Before(func() {
+		stack = images.StackImages{
+			Name: "nodejs-16",
+			Lifecycle: &images.Lifecycle{
+				SupportedUntil: "2023-09-11",
+				Replacement:    "nodejs-20",
+			},
+		}
+	})
+
+	context("Status", func() {
+		it("is supported without lifecycle metadata", func() {
+			Expect(images.StackImages{Name: "nodejs-20"}.Status(date("2030-01-01"))).To(Equal(images.Supported))
+		})
+
+		it("follows the supported-until date and the publish grace period", func() {
+			Expect(stack.Status(date("2023-09-11"))).To(Equal(images.Supported))
+			Expect(stack.Status(date("2023-09-12"))).To(Equal(images.Expired))
+			Expect(stack.Status(date("2023-12-10"))).To(Equal(images.Expired))
+			Expect(stack.Status(date("2023-12-11"))).To(Equal(images.Retired))
+		})
+
+		it("reports deprecated variants that are still supported", func() {
+			stack.Lifecycle.Deprecated = true
+
+			Expect(stack.Status(date("2023-01-01"))).To(Equal(images.Deprecated))
+			Expect(stack.Status(date("2023-09-12"))).To(Equal(images.Expired))
+		})
+	})
+
+	context("LifecycleWarning", func() {
+		it("is empty for supported variants", func() {
+			Expect(stack.LifecycleWarning(date("2023-01-01"))).To(BeEmpty())
+		})
+
+		it("explains expired variants", func() {
+			Expect(stack.LifecycleWarning(date("2023-10-01"))).To(Equal("nodejs-16 is expired, its support ended on 2023-09-11 and it can be published until 2023-12-10, use nodejs-20 instead"))
+		})
+	})
+
+	context("CheckPublishable", func() {
+		it("allows publishing within the grace period", func() {
+			Expect(stack.CheckPublishable(date("2023-12-10"))).To(Succeed())
+		})
+
+		it("refuses to publish retired variants", func() {
+			Expect(stack.CheckPublishable(date("2024-01-01"))).To(MatchError("refusing to publish nodejs-16: its support ended on 2023-09-11 and it could be published until 2023-12-10"))
+		})
+
+		it("agrees with the warning on the last day of the grace period", func() {
+			Expect(stack.LifecycleWarning(date("2023-12-10"))).To(ContainSubstring("it can be published until 2023-12-10"))
+			Expect(stack.CheckPublishable(date("2023-12-10").Add(23 * time.Hour))).To(Succeed())
+
+			Expect(stack.Status(date("2023-12-11"))).To(Equal(images.Retired))
+			Expect(stack.CheckPublishable(date("2023-12-11"))).To(MatchError(ContainSubstring("it could be published until 2023-12-10")))
+		})
+	})
+
+	context("MetadataLabel", func() {
+		it("is an empty object without lifecycle metadata", func() {
+			Expect(images.StackImages{Name: "nodejs-20"}.MetadataLabel()).To(Equal("{}"))
+		})
+
+		it("carries the lifecycle metadata", func() {
+			stack.Lifecycle.Deprecated = true
+
+			Expect(stack.MetadataLabel()).To(MatchJSON(`{
+				"lifecycle": {
+					"supported_until": "2023-09-11",
+					"deprecated": true,
+					"replacement": "nodejs-20"
+				}
+			}`))
+		})
+	})
+}
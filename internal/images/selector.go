@@ -0,0 +1,150 @@
+package images
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DefaultRunTerm selects the entries that set is_default_run_image.
+const DefaultRunTerm = "default-run"
+
+// Selector picks entries of images.json. It is parsed from a list of terms
+// separated by spaces or commas:
+//
+//	nodejs-*      entries whose name matches the glob
+//	type=java     entries of the given type
+//	distro=ubi8   entries built on the given distro
+//	default-run   entries that set is_default_run_image
+//	!<term>       excludes the entries matched by term
+//
+// An entry is selected when it matches any inclusion and no exclusion. A
+// selector made of exclusions only starts from every entry.
+type Selector struct {
+	terms []term
+}
+
+type term struct {
+	raw     string
+	exclude bool
+	match   func(StackImages) bool
+}
+
+// ParseSelector parses the selector expression. An empty expression selects
+// every entry.
+func ParseSelector(expression string) (Selector, error) {
+	var selector Selector
+	var errs []error
+
+	fields := strings.FieldsFunc(expression, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+	for _, field := range fields {
+		t := term{raw: field}
+
+		pattern := field
+		if strings.HasPrefix(pattern, "!") {
+			t.exclude = true
+			pattern = strings.TrimPrefix(pattern, "!")
+		}
+
+		key, value, isFilter := strings.Cut(pattern, "=")
+		switch {
+		case pattern == "":
+			errs = append(errs, fmt.Errorf("selector %q is empty", field))
+			continue
+		case pattern == DefaultRunTerm:
+			t.match = func(stack StackImages) bool { return stack.IsDefaultRunImage }
+		case isFilter && key == "type":
+			t.match = func(stack StackImages) bool { return stack.Type == value }
+		case isFilter && key == "distro":
+			t.match = func(stack StackImages) bool { return stack.Distro == value }
+		case isFilter:
+			errs = append(errs, fmt.Errorf("selector %q filters on unknown key %q, expected type or distro", field, key))
+			continue
+		default:
+			_, err := path.Match(pattern, "")
+			if err != nil {
+				errs = append(errs, fmt.Errorf("selector %q is not a valid glob: %w", field, err))
+				continue
+			}
+			t.match = func(stack StackImages) bool {
+				matched, _ := path.Match(pattern, stack.Name)
+				return matched
+			}
+		}
+
+		selector.terms = append(selector.terms, t)
+	}
+
+	if len(errs) > 0 {
+		return Selector{}, errors.Join(errs...)
+	}
+
+	return selector, nil
+}
+
+// Select returns the selected entries in images.json order. It fails when a
+// term matches no entry at all, which usually is a typo, or when nothing is
+// left after exclusions.
+func (s Selector) Select(imagesJson ImagesJson) ([]StackImages, error) {
+	if len(s.terms) == 0 {
+		return imagesJson.StackImages, nil
+	}
+
+	var errs []error
+	hasInclusions := false
+	for _, t := range s.terms {
+		hasInclusions = hasInclusions || !t.exclude
+
+		matched := false
+		for _, stack := range imagesJson.StackImages {
+			matched = matched || t.match(stack)
+		}
+		if !matched {
+			errs = append(errs, fmt.Errorf("selector %q matches no image", t.raw))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	var selected []StackImages
+	for _, stack := range imagesJson.StackImages {
+		included := !hasInclusions
+		excluded := false
+		for _, t := range s.terms {
+			if !t.match(stack) {
+				continue
+			}
+
+			if t.exclude {
+				excluded = true
+			} else {
+				included = true
+			}
+		}
+
+		if included && !excluded {
+			selected = append(selected, stack)
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("selector %q selects no image", s.String())
+	}
+
+	return selected, nil
+}
+
+// String returns the normalized selector expression.
+func (s Selector) String() string {
+	var terms []string
+	for _, t := range s.terms {
+		terms = append(terms, t.raw)
+	}
+
+	return strings.Join(terms, " ")
+}
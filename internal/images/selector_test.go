@@ -0,0 +1,93 @@
+package images_test
+
+import (
+	"testing"
+
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testSelector(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		imagesJson images.ImagesJson
+	)
+
+	it.Before(func() {
+		imagesJson = images.ImagesJson{
+			StackImages: []images.StackImages{
+				{Name: "default", Type: images.TypeBase, Distro: "ubi8"},
+				{Name: "java-8", Type: images.TypeJava, Distro: "ubi8"},
+				{Name: "java-17", Type: images.TypeJava, Distro: "ubi8"},
+				{Name: "nodejs-18", Type: images.TypeNodejs, Distro: "ubi8"},
+				{Name: "nodejs-20", Type: images.TypeNodejs, Distro: "ubi8", IsDefaultRunImage: true},
+				{Name: "nodejs-22", Type: images.TypeNodejs, Distro: "ubi9"},
+			},
+		}
+	})
+
+	selectNames := func(expression string) ([]string, error) {
+		selector, err := images.ParseSelector(expression)
+		if err != nil {
+			return nil, err
+		}
+
+		selected, err := selector.Select(imagesJson)
+		if err != nil {
+			return nil, err
+		}
+
+		var names []string
+		for _, stack := range selected {
+			names = append(names, stack.Name)
+		}
+		return names, nil
+	}
+
+	it("selects every entry for an empty expression", func() {
+		Expect(selectNames("")).To(HaveLen(6))
+	})
+
+	it("selects exact names and globs in images.json order", func() {
+		Expect(selectNames("nodejs-20 default")).To(Equal([]string{"default", "nodejs-20"}))
+		Expect(selectNames("nodejs-*")).To(Equal([]string{"nodejs-18", "nodejs-20", "nodejs-22"}))
+	})
+
+	it("selects by type, distro and the default run image flag", func() {
+		Expect(selectNames("type=java")).To(Equal([]string{"java-8", "java-17"}))
+		Expect(selectNames("distro=ubi9")).To(Equal([]string{"nodejs-22"}))
+		Expect(selectNames("default-run,default")).To(Equal([]string{"default", "nodejs-20"}))
+	})
+
+	it("applies exclusions", func() {
+		Expect(selectNames("type=java !java-8")).To(Equal([]string{"java-17"}))
+		Expect(selectNames("!type=nodejs !java-*")).To(Equal([]string{"default"}))
+	})
+
+	context("failure cases", func() {
+		it("reports every term that matches nothing", func() {
+			_, err := selectNames("nodejs-16 !java-7 type=java")
+			Expect(err).To(MatchError(SatisfyAll(
+				ContainSubstring(`selector "nodejs-16" matches no image`),
+				ContainSubstring(`selector "!java-7" matches no image`),
+			)))
+		})
+
+		it("fails when exclusions leave nothing", func() {
+			_, err := selectNames("java-* !type=java")
+			Expect(err).To(MatchError(`selector "java-* !type=java" selects no image`))
+		})
+
+		it("rejects malformed terms", func() {
+			_, err := images.ParseSelector("nodejs-[ arch=amd64 !")
+			Expect(err).To(MatchError(SatisfyAll(
+				ContainSubstring(`selector "nodejs-[" is not a valid glob`),
+				ContainSubstring(`selector "arch=amd64" filters on unknown key "arch", expected type or distro`),
+				ContainSubstring(`selector "!" is empty`),
+			)))
+		})
+	})
+}
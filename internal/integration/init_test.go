@@ -0,0 +1,15 @@
+package integration_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitIntegration(t *testing.T) {
+	suite := spec.New("integration", spec.Report(report.Terminal{}))
+	suite("Integration", testIntegration)
+	suite("Lock", testLock)
+	suite.Run(t)
+}
@@ -0,0 +1,180 @@
+package integration
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Filename is the location of the integration test settings relative to the
+// repository root.
+const Filename = "integration.json"
+
+type Config struct {
+	BuildPlan          Source `json:"build-plan"`
+	UbiNodejsExtension Source `json:"ubi-nodejs-extension"`
+	Nodejs             Source `json:"nodejs"`
+	GoDist             Source `json:"go-dist"`
+}
+
+// Source locates a buildpack or extension used by the acceptance suite. It is
+// either a remote URI, optionally pinned to an exact release version, or a
+// local .cnb file or buildpack directory relative to the repository root.
+type Source struct {
+	URI     string `json:"uri,omitempty"`
+	Version string `json:"version,omitempty"`
+	Path    string `json:"path,omitempty"`
+}
+
+// Sources returns the buildpacks and extensions of the configuration keyed by
+// their integration.json name.
+func (c Config) Sources() map[string]Source {
+	return map[string]Source{
+		"build-plan":           c.BuildPlan,
+		"ubi-nodejs-extension": c.UbiNodejsExtension,
+		"nodejs":               c.Nodejs,
+		"go-dist":              c.GoDist,
+	}
+}
+
+// Override replaces the named sources, e.g. to test against a local checkout
+// of a buildpack.
+func (c Config) Override(sources map[string]Source) (Config, error) {
+	if len(sources) == 0 {
+		return c, nil
+	}
+
+	known := c.Sources()
+	var errs []error
+	for _, name := range sortedNames(sources) {
+		if _, ok := known[name]; !ok {
+			errs = append(errs, fmt.Errorf("%s is not a source of %s", name, Filename))
+		}
+	}
+	if len(errs) > 0 {
+		return Config{}, errors.Join(errs...)
+	}
+
+	content, err := json.Marshal(c)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(content, &fields)
+	if err != nil {
+		return Config{}, err
+	}
+
+	for name, source := range sources {
+		fields[name], err = json.Marshal(source)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	content, err = json.Marshal(fields)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var overridden Config
+	err = json.Unmarshal(content, &overridden)
+	if err != nil {
+		return Config{}, err
+	}
+
+	return overridden, overridden.Validate()
+}
+
+// Pin pins every remote source without a version to the release it resolved
+// to, so that the configuration keeps resolving to the locked artifacts.
+func (c Config) Pin(artifacts map[string]Artifact) (Config, error) {
+	pinned := map[string]Source{}
+	for _, name := range sortedNames(c.Sources()) {
+		source := c.Sources()[name]
+		if source.URI == "" || source.Version != "" {
+			continue
+		}
+
+		artifact, ok := artifacts[name]
+		if !ok || artifact.Version == "" {
+			return Config{}, fmt.Errorf("%s: no resolved version to pin", name)
+		}
+
+		// releases are tagged v<version>
+		source.Version = strings.TrimPrefix(artifact.Version, "v")
+		pinned[name] = source
+	}
+
+	return c.Override(pinned)
+}
+
+// Write stores the configuration in the repository at root.
+func (c Config) Write(root string) error {
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(root, Filename), append(content, '\n'), 0644)
+}
+
+// Validate checks that every source is either a URI or a local path.
+func (c Config) Validate() error {
+	var errs []error
+	for _, name := range sortedNames(c.Sources()) {
+		source := c.Sources()[name]
+		switch {
+		case source.URI == "" && source.Path == "":
+			errs = append(errs, fmt.Errorf("%s: one of uri or path must be set", name))
+		case source.URI != "" && source.Path != "":
+			errs = append(errs, fmt.Errorf("%s: uri and path are mutually exclusive", name))
+		case strings.HasSuffix(source.Path, ".cnb") && source.Version != "":
+			errs = append(errs, fmt.Errorf("%s: version cannot be set for a .cnb archive", name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func sortedNames[T any](m map[string]T) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Load reads the integration test settings of the repository at root,
+// rejecting any key that is not part of the model.
+func Load(root string) (Config, error) {
+	path := filepath.Join(root, Filename)
+	file, err := os.Open(path)
+	if err != nil {
+		return Config{}, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+
+	var config Config
+	err = decoder.Decode(&config)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid %s: %w", path, err)
+	}
+
+	return config, nil
+}
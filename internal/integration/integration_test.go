@@ -0,0 +1,142 @@
+package integration_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-community/ubi-base-stack/internal/integration"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testIntegration(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		root string
+	)
+
+	it.Before(func() {
+		var err error
+		root, err = os.MkdirTemp("", "root")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	context("Load", func() {
+		it("reads pinned, unpinned and local sources", func() {
+			Expect(os.WriteFile(filepath.Join(root, integration.Filename), []byte(`{
+  "build-plan": {"path": "../build-plan"},
+  "ubi-nodejs-extension": {"path": "./ubi-nodejs-extension.cnb"},
+  "nodejs": {"uri": "github.com/paketo-buildpacks/nodejs", "version": "7.2.1"},
+  "go-dist": {"uri": "github.com/paketo-buildpacks/go-dist"}
+}`), 0644)).To(Succeed())
+
+			config, err := integration.Load(root)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config).To(Equal(integration.Config{
+				BuildPlan:          integration.Source{Path: "../build-plan"},
+				UbiNodejsExtension: integration.Source{Path: "./ubi-nodejs-extension.cnb"},
+				Nodejs:             integration.Source{URI: "github.com/paketo-buildpacks/nodejs", Version: "7.2.1"},
+				GoDist:             integration.Source{URI: "github.com/paketo-buildpacks/go-dist"},
+			}))
+		})
+
+		context("failure cases", func() {
+			it("rejects ambiguous or empty sources", func() {
+				Expect(os.WriteFile(filepath.Join(root, integration.Filename), []byte(`{
+  "build-plan": {},
+  "ubi-nodejs-extension": {"path": "./ubi-nodejs-extension.cnb", "version": "1.0.0"},
+  "nodejs": {"uri": "github.com/paketo-buildpacks/nodejs", "path": "../nodejs"},
+  "go-dist": {"uri": "github.com/paketo-buildpacks/go-dist"}
+}`), 0644)).To(Succeed())
+
+				_, err := integration.Load(root)
+				Expect(err).To(MatchError(SatisfyAll(
+					ContainSubstring("build-plan: one of uri or path must be set"),
+					ContainSubstring("nodejs: uri and path are mutually exclusive"),
+					ContainSubstring("ubi-nodejs-extension: version cannot be set for a .cnb archive"),
+				)))
+			})
+		})
+	})
+
+	context("Pin", func() {
+		it("pins unpinned remote sources to the versions they resolved to and writes them", func() {
+			config := integration.Config{
+				BuildPlan:          integration.Source{Path: "../build-plan"},
+				UbiNodejsExtension: integration.Source{URI: "github.com/paketo-community/ubi-nodejs-extension"},
+				Nodejs:             integration.Source{URI: "github.com/paketo-buildpacks/nodejs", Version: "7.2.1"},
+				GoDist:             integration.Source{URI: "github.com/paketo-buildpacks/go-dist"},
+			}
+
+			pinned, err := config.Pin(map[string]integration.Artifact{
+				"build-plan":           {Source: "../build-plan", Version: "0.1.0"},
+				"ubi-nodejs-extension": {Source: "github.com/paketo-community/ubi-nodejs-extension", Version: "v1.3.0"},
+				"nodejs":               {Source: "github.com/paketo-buildpacks/nodejs", Version: "7.2.1"},
+				"go-dist":              {Source: "github.com/paketo-buildpacks/go-dist", Version: "2.6.0"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pinned).To(Equal(integration.Config{
+				BuildPlan:          integration.Source{Path: "../build-plan"},
+				UbiNodejsExtension: integration.Source{URI: "github.com/paketo-community/ubi-nodejs-extension", Version: "1.3.0"},
+				Nodejs:             integration.Source{URI: "github.com/paketo-buildpacks/nodejs", Version: "7.2.1"},
+				GoDist:             integration.Source{URI: "github.com/paketo-buildpacks/go-dist", Version: "2.6.0"},
+			}))
+
+			Expect(pinned.Write(root)).To(Succeed())
+			Expect(integration.Load(root)).To(Equal(pinned))
+		})
+
+		context("failure cases", func() {
+			it("requires a resolved version for every unpinned source", func() {
+				config := integration.Config{
+					BuildPlan:          integration.Source{Path: "../build-plan"},
+					UbiNodejsExtension: integration.Source{Path: "./ubi-nodejs-extension.cnb"},
+					Nodejs:             integration.Source{URI: "github.com/paketo-buildpacks/nodejs", Version: "7.2.1"},
+					GoDist:             integration.Source{URI: "github.com/paketo-buildpacks/go-dist"},
+				}
+
+				_, err := config.Pin(map[string]integration.Artifact{})
+				Expect(err).To(MatchError("go-dist: no resolved version to pin"))
+			})
+		})
+	})
+
+	context("Override", func() {
+		var config integration.Config
+
+		it.Before(func() {
+			config = integration.Config{
+				BuildPlan:          integration.Source{URI: "github.com/paketo-community/build-plan"},
+				UbiNodejsExtension: integration.Source{URI: "github.com/paketo-community/ubi-nodejs-extension"},
+				Nodejs:             integration.Source{URI: "github.com/paketo-buildpacks/nodejs", Version: "7.2.1"},
+				GoDist:             integration.Source{URI: "github.com/paketo-buildpacks/go-dist"},
+			}
+		})
+
+		it("replaces the named sources as a whole", func() {
+			overridden, err := config.Override(map[string]integration.Source{
+				"nodejs": {Path: "../nodejs"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(overridden.Nodejs).To(Equal(integration.Source{Path: "../nodejs"}))
+			Expect(overridden.GoDist).To(Equal(config.GoDist))
+		})
+
+		context("failure cases", func() {
+			it("rejects unknown and invalid sources", func() {
+				_, err := config.Override(map[string]integration.Source{"java": {Path: "../java"}})
+				Expect(err).To(MatchError("java is not a source of integration.json"))
+
+				_, err = config.Override(map[string]integration.Source{"nodejs": {}})
+				Expect(err).To(MatchError("nodejs: one of uri or path must be set"))
+			})
+		})
+	})
+}
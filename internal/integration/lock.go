@@ -0,0 +1,292 @@
+package integration
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// LockFilename is the location of the lock file relative to the repository
+// root.
+const LockFilename = "integration.lock.json"
+
+// Artifact is a resolved buildpack or extension.
+type Artifact struct {
+	// Path is the location of the artifact that is handed to pack.
+	Path    string `json:"-"`
+	Source  string `json:"source"`
+	Version string `json:"version,omitempty"`
+	Digest  string `json:"digest"`
+}
+
+// ErrNoLock is returned by LoadLock when the repository does not ship a lock
+// file yet.
+var ErrNoLock = fmt.Errorf("%s does not exist, run `stack-tools lock` to create it", LockFilename)
+
+// Lock records the artifact every source of integration.json resolved to
+// when the lock file was last written.
+type Lock map[string]Artifact
+
+// Store fetches buildpack artifacts. A non-empty version pins remote URIs to
+// a release and is used as the packaging version for local directories.
+type Store interface {
+	Get(uri string, version string) (string, error)
+}
+
+// Resolve fetches every source of config through store.
+func Resolve(root string, config Config, store Store) (map[string]Artifact, error) {
+	artifacts := map[string]Artifact{}
+	for name, source := range config.Sources() {
+		artifact, err := ResolveSource(root, source, store)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", name, err)
+		}
+		artifacts[name] = artifact
+	}
+
+	return artifacts, nil
+}
+
+// ResolveSource fetches a single source through store and records the
+// version and digest of what it resolved to.
+func ResolveSource(root string, source Source, store Store) (Artifact, error) {
+	if source.Path == "" {
+		path, err := store.Get(source.URI, source.Version)
+		if err != nil {
+			return Artifact{}, err
+		}
+
+		version := source.Version
+		if version == "" {
+			// the buildpack store caches unpinned releases as <tag>.tgz
+			version = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+
+		digest, err := fileDigest(path)
+		if err != nil {
+			return Artifact{}, err
+		}
+
+		return Artifact{Path: path, Source: source.URI, Version: version, Digest: digest}, nil
+	}
+
+	path := filepath.Join(root, source.Path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return Artifact{}, err
+	}
+
+	if !info.IsDir() {
+		digest, err := fileDigest(path)
+		if err != nil {
+			return Artifact{}, err
+		}
+
+		return Artifact{Path: path, Source: source.Path, Digest: digest}, nil
+	}
+
+	version := source.Version
+	if version == "" {
+		version, err = descriptorVersion(path)
+		if err != nil {
+			return Artifact{}, err
+		}
+	}
+
+	// packaging a directory is not reproducible, so the digest covers the
+	// sources that were packaged instead of the resulting archive
+	digest, err := dirDigest(path)
+	if err != nil {
+		return Artifact{}, err
+	}
+
+	packaged, err := store.Get(path, version)
+	if err != nil {
+		return Artifact{}, err
+	}
+
+	return Artifact{Path: packaged, Source: source.Path, Version: version, Digest: digest}, nil
+}
+
+// LoadLock reads the lock file of the repository at root. It returns
+// ErrNoLock when there is none.
+func LoadLock(root string) (Lock, error) {
+	path := filepath.Join(root, LockFilename)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNoLock
+		}
+		return nil, err
+	}
+
+	var lock Lock
+	err = json.Unmarshal(content, &lock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return lock, nil
+}
+
+// Write stores the lock file in the repository at root.
+func (l Lock) Write(root string) error {
+	content, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(root, LockFilename), append(content, '\n'), 0644)
+}
+
+// Verify checks that every resolved artifact matches its lock entry.
+func (l Lock) Verify(artifacts map[string]Artifact) error {
+	var errs []error
+	for _, name := range sortedNames(artifacts) {
+		artifact := artifacts[name]
+		locked, ok := l[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s is not locked", name))
+			continue
+		}
+
+		if locked != (Artifact{Source: artifact.Source, Version: artifact.Version, Digest: artifact.Digest}) {
+			errs = append(errs, fmt.Errorf("%s resolved to %s@%s (%s) but %s@%s (%s) is locked", name, artifact.Source, artifact.Version, artifact.Digest, locked.Source, locked.Version, locked.Digest))
+		}
+	}
+
+	for _, name := range sortedNames(l) {
+		if _, ok := artifacts[name]; !ok {
+			errs = append(errs, fmt.Errorf("%s is locked but no longer part of %s", name, Filename))
+		}
+	}
+
+	if len(errs) > 0 {
+		errs = append(errs, fmt.Errorf("run `stack-tools lock` to accept the resolved artifacts"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Check compares the lock with the sources of config without resolving
+// them: every source must be locked from the same location and, when it is
+// pinned, at the same version.
+func (l Lock) Check(config Config) error {
+	sources := config.Sources()
+
+	var errs []error
+	for _, name := range sortedNames(sources) {
+		source := sources[name]
+		locked, ok := l[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s is not locked", name))
+			continue
+		}
+
+		location := source.URI
+		if source.Path != "" {
+			location = source.Path
+		}
+
+		if locked.Source != location || (source.Version != "" && locked.Version != source.Version) {
+			errs = append(errs, fmt.Errorf("%s is %s@%s in %s but %s@%s is locked", name, location, source.Version, Filename, locked.Source, locked.Version))
+		}
+	}
+
+	for _, name := range sortedNames(l) {
+		if _, ok := sources[name]; !ok {
+			errs = append(errs, fmt.Errorf("%s is locked but no longer part of %s", name, Filename))
+		}
+	}
+
+	if len(errs) > 0 {
+		errs = append(errs, fmt.Errorf("run `stack-tools lock` to update %s", LockFilename))
+	}
+
+	return errors.Join(errs...)
+}
+
+func fileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil)), nil
+}
+
+func dirDigest(dir string) (string, error) {
+	hash := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		digest, err := fileDigest(path)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(hash, "%s %s\n", filepath.ToSlash(rel), digest)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("sha256:%x", hash.Sum(nil)), nil
+}
+
+// descriptorVersion reads the version declared in the buildpack.toml or
+// extension.toml of a local directory.
+func descriptorVersion(dir string) (string, error) {
+	var descriptor struct {
+		Buildpack struct {
+			Version string `toml:"version"`
+		} `toml:"buildpack"`
+		Extension struct {
+			Version string `toml:"version"`
+		} `toml:"extension"`
+	}
+
+	for _, name := range []string{"buildpack.toml", "extension.toml"} {
+		_, err := toml.DecodeFile(filepath.Join(dir, name), &descriptor)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if descriptor.Buildpack.Version != "" {
+			return descriptor.Buildpack.Version, nil
+		}
+		return descriptor.Extension.Version, nil
+	}
+
+	return "", fmt.Errorf("%s contains neither buildpack.toml nor extension.toml", dir)
+}
@@ -0,0 +1,172 @@
+package integration_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-community/ubi-base-stack/internal/integration"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+type fakeStore struct {
+	paths map[string]string
+	calls []string
+}
+
+func (f *fakeStore) Get(uri string, version string) (string, error) {
+	f.calls = append(f.calls, uri+"@"+version)
+	return f.paths[uri], nil
+}
+
+func testLock(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		root  string
+		store *fakeStore
+	)
+
+	it.Before(func() {
+		var err error
+		root, err = os.MkdirTemp("", "root")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(root, "cache", "build-plan"), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "cache", "go-dist-2.5.0.tgz"), []byte("go-dist"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "cache", "nodejs.tgz"), []byte("nodejs"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "cache", "build-plan.tgz"), []byte("packaged"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "extension.cnb"), []byte("extension"), 0644)).To(Succeed())
+
+		Expect(os.MkdirAll(filepath.Join(root, "build-plan"), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, "build-plan", "buildpack.toml"), []byte("[buildpack]\n  version = \"0.1.2\"\n"), 0644)).To(Succeed())
+
+		store = &fakeStore{paths: map[string]string{
+			"github.com/paketo-buildpacks/go-dist": filepath.Join(root, "cache", "go-dist-2.5.0.tgz"),
+			"github.com/paketo-buildpacks/nodejs":  filepath.Join(root, "cache", "nodejs.tgz"),
+			filepath.Join(root, "build-plan"):      filepath.Join(root, "cache", "build-plan.tgz"),
+		}}
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	resolve := func() map[string]integration.Artifact {
+		artifacts, err := integration.Resolve(root, integration.Config{
+			BuildPlan:          integration.Source{Path: "build-plan"},
+			UbiNodejsExtension: integration.Source{Path: "extension.cnb"},
+			Nodejs:             integration.Source{URI: "github.com/paketo-buildpacks/nodejs", Version: "7.2.1"},
+			GoDist:             integration.Source{URI: "github.com/paketo-buildpacks/go-dist"},
+		}, store)
+		Expect(err).NotTo(HaveOccurred())
+
+		return artifacts
+	}
+
+	context("Resolve", func() {
+		it("records the version and digest of every source", func() {
+			artifacts := resolve()
+
+			Expect(store.calls).To(ConsistOf(
+				"github.com/paketo-buildpacks/nodejs@7.2.1",
+				"github.com/paketo-buildpacks/go-dist@",
+				filepath.Join(root, "build-plan")+"@0.1.2",
+			))
+
+			Expect(artifacts["nodejs"]).To(Equal(integration.Artifact{
+				Path:    filepath.Join(root, "cache", "nodejs.tgz"),
+				Source:  "github.com/paketo-buildpacks/nodejs",
+				Version: "7.2.1",
+				Digest:  "sha256:81df1af4ed72b1b82fed99c73be4831908af977f3bd52c7cb7dfc738e38571dd",
+			}))
+			Expect(artifacts["go-dist"].Version).To(Equal("go-dist-2.5.0"))
+			Expect(artifacts["go-dist"].Digest).To(HavePrefix("sha256:"))
+			Expect(artifacts["build-plan"].Path).To(Equal(filepath.Join(root, "cache", "build-plan.tgz")))
+			Expect(artifacts["build-plan"].Version).To(Equal("0.1.2"))
+			Expect(artifacts["ubi-nodejs-extension"].Path).To(Equal(filepath.Join(root, "extension.cnb")))
+			Expect(artifacts["ubi-nodejs-extension"].Version).To(BeEmpty())
+		})
+	})
+
+	context("Lock", func() {
+		it("round-trips and verifies matching artifacts", func() {
+			artifacts := resolve()
+			Expect(integration.Lock(artifacts).Write(root)).To(Succeed())
+
+			lock, err := integration.LoadLock(root)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lock["nodejs"].Path).To(BeEmpty())
+			Expect(lock.Verify(resolve())).To(Succeed())
+		})
+
+		it("checks the lock against the sources without resolving them", func() {
+			lock := integration.Lock(resolve())
+
+			Expect(lock.Check(integration.Config{
+				BuildPlan:          integration.Source{Path: "build-plan"},
+				UbiNodejsExtension: integration.Source{Path: "extension.cnb"},
+				Nodejs:             integration.Source{URI: "github.com/paketo-buildpacks/nodejs", Version: "7.2.1"},
+				GoDist:             integration.Source{URI: "github.com/paketo-buildpacks/go-dist"},
+			})).To(Succeed())
+		})
+
+		it("accepts the lock file of the repository", func() {
+			repository := filepath.Join("..", "..")
+			config, err := integration.Load(repository)
+			Expect(err).NotTo(HaveOccurred())
+
+			// the acceptance suite runs unverified until a lock is shipped
+			lock, err := integration.LoadLock(repository)
+			if errors.Is(err, integration.ErrNoLock) {
+				return
+			}
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lock.Check(config)).To(Succeed())
+		})
+
+		context("failure cases", func() {
+			it("refuses artifacts that differ from the lock", func() {
+				Expect(integration.Lock(resolve()).Write(root)).To(Succeed())
+				lock, err := integration.LoadLock(root)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(os.WriteFile(filepath.Join(root, "build-plan", "bin"), []byte("changed"), 0644)).To(Succeed())
+
+				err = lock.Verify(resolve())
+				Expect(err).To(MatchError(SatisfyAll(
+					ContainSubstring("build-plan resolved to build-plan@0.1.2"),
+					ContainSubstring("run `stack-tools lock` to accept the resolved artifacts"),
+				)))
+			})
+
+			it("reports a missing lock file", func() {
+				_, err := integration.LoadLock(root)
+				Expect(err).To(MatchError(integration.ErrNoLock))
+				Expect(err).To(MatchError("integration.lock.json does not exist, run `stack-tools lock` to create it"))
+			})
+
+			it("refuses a lock that does not match the sources", func() {
+				lock := integration.Lock(resolve())
+				delete(lock, "go-dist")
+				lock["nodejs"] = integration.Artifact{Source: "github.com/paketo-buildpacks/nodejs", Version: "7.2.0"}
+				lock["removed"] = integration.Artifact{Source: "github.com/paketo-buildpacks/removed"}
+
+				err := lock.Check(integration.Config{
+					BuildPlan:          integration.Source{Path: "build-plan"},
+					UbiNodejsExtension: integration.Source{Path: "extension.cnb"},
+					Nodejs:             integration.Source{URI: "github.com/paketo-buildpacks/nodejs", Version: "7.2.1"},
+					GoDist:             integration.Source{URI: "github.com/paketo-buildpacks/go-dist"},
+				})
+				Expect(err).To(MatchError(SatisfyAll(
+					ContainSubstring("go-dist is not locked"),
+					ContainSubstring("nodejs is github.com/paketo-buildpacks/nodejs@7.2.1 in integration.json but github.com/paketo-buildpacks/nodejs@7.2.0 is locked"),
+					ContainSubstring("removed is locked but no longer part of integration.json"),
+				)))
+			})
+		})
+	})
+}
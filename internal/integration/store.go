@@ -0,0 +1,167 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ForestEckhardt/freezer/github"
+	"github.com/paketo-buildpacks/occam"
+)
+
+// BuildpackStore fetches unpinned and local buildpacks through the occam
+// buildpack store, and downloads pinned github.com releases directly since
+// the occam store only ever fetches the latest release.
+type BuildpackStore struct {
+	store    occam.BuildpackStore
+	releases github.ReleaseService
+	endpoint string
+	token    string
+	cacheDir string
+}
+
+func NewBuildpackStore() BuildpackStore {
+	token := os.Getenv("GIT_TOKEN")
+	endpoint := "https://api.github.com"
+
+	return BuildpackStore{
+		store:    occam.NewBuildpackStore(),
+		releases: github.NewReleaseService(github.NewConfig(endpoint, token)),
+		endpoint: endpoint,
+		token:    token,
+		cacheDir: filepath.Join(os.Getenv("HOME"), ".freezer-cache"),
+	}
+}
+
+func (s BuildpackStore) Get(uri string, version string) (string, error) {
+	if version == "" || !strings.HasPrefix(uri, "github.com") {
+		return s.store.Get.WithVersion(version).Execute(uri)
+	}
+
+	request := strings.SplitN(uri, "/", 3)
+	if len(request) < 3 {
+		return "", fmt.Errorf("error incomplete github.com url: %q", uri)
+	}
+	org, repo := request[1], request[2]
+
+	dir := filepath.Join(s.cacheDir, org, repo, "pinned")
+	for _, extension := range assetExtensions {
+		path := filepath.Join(dir, version+extension)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	pinned, err := s.release(org, repo, version)
+	if err != nil {
+		return "", err
+	}
+
+	asset, extension, err := pinned.buildpackAsset()
+	if err != nil {
+		return "", fmt.Errorf("release %s of %s/%s: %w", version, org, repo, err)
+	}
+
+	bundle, err := s.releases.GetReleaseAsset(github.ReleaseAsset{URL: asset.URL})
+	if err != nil {
+		return "", err
+	}
+	defer bundle.Close()
+
+	err = os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return "", err
+	}
+
+	// the download only lands in the cache once it is complete, so that an
+	// interrupted download is not mistaken for a cached release
+	file, err := os.CreateTemp(dir, fmt.Sprintf(".%s-*%s", version, extension))
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = io.Copy(file, bundle)
+	if err != nil {
+		file.Close()
+		return "", fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, version+extension)
+	err = os.Rename(file.Name(), path)
+	if err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// assetExtensions are the extensions of the release assets that hold a
+// buildpack, in order of preference.
+var assetExtensions = []string{".tgz", ".cnb"}
+
+type release struct {
+	Assets []releaseAsset `json:"assets"`
+}
+
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// buildpackAsset picks the asset holding the buildpack among the assets of
+// the release, which also lists checksums and other files.
+func (r release) buildpackAsset() (releaseAsset, string, error) {
+	for _, extension := range assetExtensions {
+		for _, asset := range r.Assets {
+			if strings.HasSuffix(asset.Name, extension) {
+				return asset, extension, nil
+			}
+		}
+	}
+
+	var names []string
+	for _, asset := range r.Assets {
+		names = append(names, asset.Name)
+	}
+
+	return releaseAsset{}, "", fmt.Errorf("no %s asset among %q", strings.Join(assetExtensions, " or "), names)
+}
+
+func (s BuildpackStore) release(org, repo, version string) (release, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/repos/%s/%s/releases/tags/v%s", s.endpoint, org, repo, version), nil)
+	if err != nil {
+		return release{}, err
+	}
+
+	if s.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("token %s", s.token))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return release{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return release{}, fmt.Errorf("failed to find release %s of %s/%s: unexpected response status: %s", version, org, repo, resp.Status)
+	}
+
+	var r release
+	err = json.NewDecoder(resp.Body).Decode(&r)
+	if err != nil {
+		return release{}, err
+	}
+
+	return r, nil
+}
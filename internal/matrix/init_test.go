@@ -0,0 +1,14 @@
+package matrix_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitMatrix(t *testing.T) {
+	suite := spec.New("matrix", spec.Report(report.Terminal{}))
+	suite("Matrix", testMatrix)
+	suite.Run(t)
+}
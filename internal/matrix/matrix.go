@@ -0,0 +1,175 @@
+package matrix
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/paketo-community/ubi-base-stack/internal/descriptor"
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+)
+
+// Matrix is the build and test matrix of the release workflow.
+type Matrix struct {
+	Stacks []Stack `json:"stacks"`
+	// Architectures lists every architecture at least one variant is built
+	// for.
+	Architectures []Architecture `json:"architectures"`
+	// Include pairs every variant with each of its own architectures, for
+	// use as a strategy.matrix.include list.
+	Include []Job `json:"include"`
+	// Retired lists the variants left out because they are past the publish
+	// grace period.
+	Retired []string `json:"retired"`
+}
+
+// Stack is an images.json entry along with the platforms of its stack.toml.
+type Stack struct {
+	Name                    string   `json:"name"`
+	ConfigDir               string   `json:"config_dir"`
+	OutputDir               string   `json:"output_dir"`
+	BuildImage              string   `json:"build_image"`
+	BuildReceiptFilename    string   `json:"build_receipt_filename"`
+	RunImage                string   `json:"run_image"`
+	RunReceiptFilename      string   `json:"run_receipt_filename"`
+	CreateBuildImage        bool     `json:"create_build_image"`
+	BaseBuildContainerImage string   `json:"base_build_container_image,omitempty"`
+	BaseRunContainerImage   string   `json:"base_run_container_image"`
+	Distro                  string   `json:"distro"`
+	Platforms               []string `json:"platforms"`
+	// IsNew is true for variants the previous release did not ship.
+	IsNew bool `json:"is_new"`
+}
+
+// Architecture is a platform without its operating system, e.g. amd64 for
+// linux/amd64.
+type Architecture struct {
+	Name     string `json:"name"`
+	Platform string `json:"platform"`
+	// IsNew is true when the previous release did not ship the architecture,
+	// or, within a Job, did not ship it for that variant.
+	IsNew bool `json:"is_new"`
+}
+
+// Job is a single variant and architecture combination. The keys match the
+// matrix.stacks and matrix.arch names used by the workflows.
+type Job struct {
+	Stack Stack        `json:"stacks"`
+	Arch  Architecture `json:"arch"`
+}
+
+// Previous records the platforms every variant of the previous release was
+// built for, keyed by variant name.
+type Previous map[string][]string
+
+// LoadPrevious reads the variants of a checkout of the previous release at
+// root. A checkout without images.json yields no variants, so everything is
+// reported as new.
+func LoadPrevious(root string) (Previous, error) {
+	content, err := os.ReadFile(filepath.Join(root, images.Filename))
+	if errors.Is(err, os.ErrNotExist) {
+		return Previous{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// older releases may predate fields the current model requires, so only
+	// the keys needed to find the stack.toml files are decoded
+	var imagesJson struct {
+		Images []struct {
+			Name      string `json:"name"`
+			ConfigDir string `json:"config_dir"`
+		} `json:"images"`
+	}
+	err = json.Unmarshal(content, &imagesJson)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Join(root, images.Filename), err)
+	}
+
+	previous := Previous{}
+	for _, image := range imagesJson.Images {
+		stack, err := descriptor.Load(filepath.Join(root, image.ConfigDir, descriptor.Filename))
+		if err != nil {
+			return nil, err
+		}
+		previous[image.Name] = stack.Platforms
+	}
+
+	return previous, nil
+}
+
+// Build computes the matrix of the variants in imagesJson, comparing them
+// with the previous release. Variants that are retired at now are left out,
+// as they must not be published anymore.
+func Build(root string, imagesJson images.ImagesJson, previous Previous, now time.Time) (Matrix, error) {
+	stacks, err := descriptor.LoadAll(root, imagesJson)
+	if err != nil {
+		return Matrix{}, err
+	}
+
+	matrix := Matrix{
+		Stacks:        []Stack{},
+		Architectures: []Architecture{},
+		Include:       []Job{},
+		Retired:       []string{},
+	}
+	architectures := map[string]int{}
+
+	for _, image := range imagesJson.StackImages {
+		if image.Status(now) == images.Retired {
+			matrix.Retired = append(matrix.Retired, image.Name)
+			continue
+		}
+
+		platforms := stacks[image.Name].Platforms
+		if len(platforms) == 0 {
+			return Matrix{}, fmt.Errorf("%s: %s declares no platforms", image.Name, filepath.Join(image.ConfigDir, descriptor.Filename))
+		}
+
+		previousPlatforms, shipped := previous[image.Name]
+
+		stack := Stack{
+			Name:                    image.Name,
+			ConfigDir:               image.ConfigDir,
+			OutputDir:               image.OutputDir,
+			BuildImage:              image.BuildImage,
+			BuildReceiptFilename:    image.BuildReceiptFilename,
+			RunImage:                image.RunImage,
+			RunReceiptFilename:      image.RunReceiptFilename,
+			CreateBuildImage:        image.CreateBuildImage,
+			BaseBuildContainerImage: image.BaseBuildContainerImage,
+			BaseRunContainerImage:   image.BaseRunContainerImage,
+			Distro:                  image.Distro,
+			Platforms:               platforms,
+			IsNew:                   !shipped,
+		}
+		matrix.Stacks = append(matrix.Stacks, stack)
+
+		for _, platform := range platforms {
+			arch := Architecture{
+				Name:     strings.TrimPrefix(platform, "linux/"),
+				Platform: platform,
+				IsNew:    !slices.Contains(previousPlatforms, platform),
+			}
+			matrix.Include = append(matrix.Include, Job{Stack: stack, Arch: arch})
+
+			index, ok := architectures[platform]
+			if !ok {
+				architectures[platform] = len(matrix.Architectures)
+				matrix.Architectures = append(matrix.Architectures, arch)
+				continue
+			}
+
+			// an architecture is only new if no variant shipped it before
+			matrix.Architectures[index].IsNew = matrix.Architectures[index].IsNew && arch.IsNew
+		}
+	}
+
+	return matrix, nil
+}
@@ -0,0 +1,183 @@
+package matrix_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/paketo-community/ubi-base-stack/internal/matrix"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testMatrix(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		root       string
+		imagesJson images.ImagesJson
+		now        = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	)
+
+	writeStack := func(root, configDir, platforms string) {
+		Expect(os.MkdirAll(filepath.Join(root, configDir), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(root, configDir, "stack.toml"), []byte(`id = "io.buildpacks.stacks.ubi8"
+platforms = `+platforms+`
+`), 0644)).To(Succeed())
+	}
+
+	it.Before(func() {
+		var err error
+		root, err = os.MkdirTemp("", "root")
+		Expect(err).NotTo(HaveOccurred())
+
+		writeStack(root, "stacks/stack", `["linux/amd64", "linux/arm64"]`)
+		writeStack(root, "stacks/stack-nodejs-20", `["linux/amd64"]`)
+
+		imagesJson = images.ImagesJson{
+			StackImages: []images.StackImages{
+				{
+					Name:                  "default",
+					ConfigDir:             "stacks/stack",
+					OutputDir:             "builds/build",
+					BuildImage:            "build",
+					RunImage:              "run",
+					BuildReceiptFilename:  "build-receipt.cyclonedx.json",
+					RunReceiptFilename:    "run-receipt.cyclonedx.json",
+					CreateBuildImage:      true,
+					BaseRunContainerImage: "docker://registry.access.redhat.com/ubi8/ubi-minimal",
+					Distro:                "ubi8",
+				},
+				{
+					Name:                  "nodejs-20",
+					ConfigDir:             "stacks/stack-nodejs-20",
+					OutputDir:             "builds/build-nodejs-20",
+					BuildImage:            "build-nodejs-20",
+					RunImage:              "run-nodejs-20",
+					BuildReceiptFilename:  "build-nodejs-20-receipt.cyclonedx.json",
+					RunReceiptFilename:    "run-nodejs-20-receipt.cyclonedx.json",
+					BaseRunContainerImage: "docker://registry.access.redhat.com/ubi8/nodejs-20-minimal",
+					Distro:                "ubi8",
+				},
+			},
+		}
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	context("Build", func() {
+		it("pairs every variant with its own platforms", func() {
+			m, err := matrix.Build(root, imagesJson, matrix.Previous{}, now)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(m.Stacks).To(HaveLen(2))
+			Expect(m.Stacks[0]).To(Equal(matrix.Stack{
+				Name:                  "default",
+				ConfigDir:             "stacks/stack",
+				OutputDir:             "builds/build",
+				BuildImage:            "build",
+				BuildReceiptFilename:  "build-receipt.cyclonedx.json",
+				RunImage:              "run",
+				RunReceiptFilename:    "run-receipt.cyclonedx.json",
+				CreateBuildImage:      true,
+				BaseRunContainerImage: "docker://registry.access.redhat.com/ubi8/ubi-minimal",
+				Distro:                "ubi8",
+				Platforms:             []string{"linux/amd64", "linux/arm64"},
+				IsNew:                 true,
+			}))
+
+			Expect(m.Architectures).To(Equal([]matrix.Architecture{
+				{Name: "amd64", Platform: "linux/amd64", IsNew: true},
+				{Name: "arm64", Platform: "linux/arm64", IsNew: true},
+			}))
+
+			var jobs []string
+			for _, job := range m.Include {
+				jobs = append(jobs, job.Stack.Name+"/"+job.Arch.Name)
+			}
+			Expect(jobs).To(Equal([]string{"default/amd64", "default/arm64", "nodejs-20/amd64"}))
+		})
+
+		it("marks what the previous release did not ship as new", func() {
+			m, err := matrix.Build(root, imagesJson, matrix.Previous{
+				"nodejs-20": {"linux/amd64", "linux/arm64"},
+			}, now)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(m.Stacks[0].IsNew).To(BeTrue())
+			Expect(m.Stacks[1].IsNew).To(BeFalse())
+
+			Expect(m.Architectures).To(Equal([]matrix.Architecture{
+				{Name: "amd64", Platform: "linux/amd64", IsNew: false},
+				{Name: "arm64", Platform: "linux/arm64", IsNew: true},
+			}))
+
+			Expect(m.Include[0].Arch.IsNew).To(BeTrue())
+			Expect(m.Include[2].Arch.IsNew).To(BeFalse())
+		})
+
+		it("leaves out retired variants", func() {
+			imagesJson.StackImages[1].Lifecycle = &images.Lifecycle{SupportedUntil: "2024-01-01"}
+
+			m, err := matrix.Build(root, imagesJson, matrix.Previous{}, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Retired).To(Equal([]string{"nodejs-20"}))
+
+			for _, stack := range m.Stacks {
+				Expect(stack.Name).NotTo(Equal("nodejs-20"))
+			}
+			for _, job := range m.Include {
+				Expect(job.Stack.Name).NotTo(Equal("nodejs-20"))
+			}
+
+			imagesJson.StackImages[1].Lifecycle.SupportedUntil = "2024-05-01"
+			m, err = matrix.Build(root, imagesJson, matrix.Previous{}, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Retired).To(BeEmpty())
+			Expect(m.Stacks).To(HaveLen(2))
+		})
+
+		context("failure cases", func() {
+			it("fails for stacks without platforms", func() {
+				writeStack(root, "stacks/stack-nodejs-20", `[]`)
+
+				_, err := matrix.Build(root, imagesJson, matrix.Previous{}, now)
+				Expect(err).To(MatchError("nodejs-20: stacks/stack-nodejs-20/stack.toml declares no platforms"))
+			})
+		})
+	})
+
+	context("LoadPrevious", func() {
+		var previous string
+
+		it.Before(func() {
+			var err error
+			previous, err = os.MkdirTemp("", "previous")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(previous)).To(Succeed())
+		})
+
+		it("reads the platforms of every variant, ignoring fields it does not know", func() {
+			writeStack(previous, "stacks/stack", `["linux/amd64"]`)
+			Expect(os.WriteFile(filepath.Join(previous, images.Filename), []byte(`{
+  "images": [{"name": "default", "config_dir": "stacks/stack", "retired_field": true}]
+}`), 0644)).To(Succeed())
+
+			Expect(matrix.LoadPrevious(previous)).To(Equal(matrix.Previous{
+				"default": {"linux/amd64"},
+			}))
+		})
+
+		it("treats a checkout without images.json as an empty release", func() {
+			Expect(matrix.LoadPrevious(previous)).To(Equal(matrix.Previous{}))
+		})
+	})
+}
@@ -0,0 +1,14 @@
+package profiles_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitProfiles(t *testing.T) {
+	suite := spec.New("profiles", spec.Report(report.Terminal{}))
+	suite("Profiles", testProfiles)
+	suite.Run(t)
+}
@@ -0,0 +1,210 @@
+package profiles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/paketo-community/ubi-base-stack/internal/integration"
+	"github.com/paketo-community/ubi-base-stack/internal/registries"
+)
+
+// Filename is the location of the environment profiles relative to the
+// repository root.
+const Filename = "profiles.json"
+
+// EnvVar selects the profile of the acceptance suite when it is not given
+// with -profile.
+const EnvVar = "STACK_PROFILE"
+
+// LifecyclesEnvVar holds comma separated lifecycle archives that replace the
+// lifecycles of the profile when they are not given with -lifecycles.
+const LifecyclesEnvVar = "STACK_LIFECYCLES"
+
+// Pull policies pack build accepts.
+const (
+	PullAlways       = "always"
+	PullIfNotPresent = "if-not-present"
+	PullNever        = "never"
+)
+
+type Profiles struct {
+	// Default is the profile used when none is selected.
+	Default  string             `json:"default"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// Profile bundles the settings that differ between the environments the
+// stack is tested and published in.
+type Profile struct {
+	// RegistryURL is the registry the acceptance suite pushes its builders
+	// to. Environment variables are expanded, e.g. ${STAGING_REGISTRY_URL}.
+	RegistryURL string `json:"registry_url,omitempty"`
+	// SetupLocalRegistry makes scripts/test.sh start a throwaway registry
+	// instead.
+	SetupLocalRegistry bool `json:"setup_local_registry,omitempty"`
+	// PublishTargets names the registries.json targets images are pushed to.
+	PublishTargets []string `json:"publish_targets"`
+	// PullPolicy is the pull policy of pack build.
+	PullPolicy string `json:"pull_policy"`
+	// Sources replaces integration.json sources by name.
+	Sources map[string]integration.Source `json:"sources,omitempty"`
+	// Lifecycles are local lifecycle archives, relative to the repository
+	// root, the acceptance suite runs the integration suites against, one
+	// builder each. The lifecycle pack bundles is used when empty.
+	Lifecycles []string `json:"lifecycles,omitempty"`
+}
+
+// Load reads the profiles of the repository at root, rejecting any key that
+// is not part of the model.
+func Load(root string) (Profiles, error) {
+	path := filepath.Join(root, Filename)
+	file, err := os.Open(path)
+	if err != nil {
+		return Profiles{}, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+
+	var profiles Profiles
+	err = decoder.Decode(&profiles)
+	if err != nil {
+		return Profiles{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	err = profiles.Validate()
+	if err != nil {
+		return Profiles{}, fmt.Errorf("invalid %s: %w", path, err)
+	}
+
+	return profiles, nil
+}
+
+// Validate checks that the default profile exists and that every profile
+// picks one registry setup, a known pull policy and distinct local lifecycle
+// archives.
+func (p Profiles) Validate() error {
+	var errs []error
+
+	if _, ok := p.Profiles[p.Default]; !ok {
+		errs = append(errs, fmt.Errorf("default profile %q is not declared", p.Default))
+	}
+
+	for _, name := range p.Names() {
+		profile := p.Profiles[name]
+
+		switch {
+		case profile.RegistryURL == "" && !profile.SetupLocalRegistry:
+			errs = append(errs, fmt.Errorf("%s: one of registry_url or setup_local_registry must be set", name))
+		case profile.RegistryURL != "" && profile.SetupLocalRegistry:
+			errs = append(errs, fmt.Errorf("%s: registry_url and setup_local_registry are mutually exclusive", name))
+		}
+
+		seen := map[string]bool{}
+		for index, lifecycle := range profile.Lifecycles {
+			switch {
+			case lifecycle == "":
+				errs = append(errs, fmt.Errorf("%s: lifecycle %d must not be empty", name, index))
+			case strings.Contains(lifecycle, "://"):
+				errs = append(errs, fmt.Errorf("%s: lifecycle %q must be a local archive", name, lifecycle))
+			case seen[lifecycle]:
+				errs = append(errs, fmt.Errorf("%s: lifecycle %q is listed more than once", name, lifecycle))
+			}
+			seen[lifecycle] = true
+		}
+
+		switch profile.PullPolicy {
+		case PullAlways, PullIfNotPresent, PullNever:
+		default:
+			errs = append(errs, fmt.Errorf("%s: pull_policy %q must be one of %q", name, profile.PullPolicy, []string{PullAlways, PullIfNotPresent, PullNever}))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// CheckReferences fails when a profile names a publish target or a source
+// that does not exist.
+func (p Profiles) CheckReferences(targets registries.Registries, config integration.Config) error {
+	var errs []error
+	for _, name := range p.Names() {
+		profile := p.Profiles[name]
+
+		_, err := targets.Select(profile.PublishTargets)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: publish_targets: %w", name, err))
+		}
+
+		_, err = config.Override(profile.Sources)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: sources: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Names returns the declared profiles, sorted.
+func (p Profiles) Names() []string {
+	var names []string
+	for name := range p.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Select returns the named profile, or the default profile for an empty
+// name.
+func (p Profiles) Select(name string) (string, Profile, error) {
+	if name == "" {
+		name = p.Default
+	}
+
+	profile, ok := p.Profiles[name]
+	if !ok {
+		return "", Profile{}, fmt.Errorf("unknown profile %q, expected one of %q", name, p.Names())
+	}
+
+	return name, profile, nil
+}
+
+// Registry returns the registry the acceptance suite pushes to. An explicit
+// REGISTRY_URL, which scripts/test.sh sets for the local registry, wins over
+// the profile.
+func (p Profile) Registry() (string, error) {
+	if url := os.Getenv("REGISTRY_URL"); url != "" {
+		return url, nil
+	}
+
+	url := os.ExpandEnv(p.RegistryURL)
+	if url == "" {
+		if p.SetupLocalRegistry {
+			return "", errors.New("the profile expects a local registry, run the suite through scripts/test.sh or set REGISTRY_URL")
+		}
+		return "", fmt.Errorf("registry_url %q expands to an empty string", p.RegistryURL)
+	}
+
+	return url, nil
+}
+
+// LifecycleArchives returns the paths of the lifecycle archives of the
+// profile, resolved against root.
+func (p Profile) LifecycleArchives(root string) []string {
+	var paths []string
+	for _, lifecycle := range p.Lifecycles {
+		if !filepath.IsAbs(lifecycle) {
+			lifecycle = filepath.Join(root, lifecycle)
+		}
+		paths = append(paths, lifecycle)
+	}
+
+	return paths
+}
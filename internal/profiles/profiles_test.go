@@ -0,0 +1,159 @@
+package profiles_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-community/ubi-base-stack/internal/integration"
+	"github.com/paketo-community/ubi-base-stack/internal/profiles"
+	"github.com/paketo-community/ubi-base-stack/internal/registries"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testProfiles(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		root string
+	)
+
+	it.Before(func() {
+		var err error
+		root, err = os.MkdirTemp("", "root")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	writeProfiles := func(content string) {
+		Expect(os.WriteFile(filepath.Join(root, profiles.Filename), []byte(content), 0644)).To(Succeed())
+	}
+
+	context("Load", func() {
+		it("reads the profiles and selects the default one", func() {
+			writeProfiles(`{
+  "default": "local",
+  "profiles": {
+    "local": {
+      "setup_local_registry": true,
+      "publish_targets": [],
+      "pull_policy": "if-not-present",
+      "sources": {"nodejs": {"path": "../nodejs"}}
+    },
+    "staging": {
+      "registry_url": "${STAGING_REGISTRY_URL}",
+      "publish_targets": ["gcr"],
+      "pull_policy": "always"
+    }
+  }
+}`)
+
+			p, err := profiles.Load(root)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Names()).To(Equal([]string{"local", "staging"}))
+
+			name, profile, err := p.Select("")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(Equal("local"))
+			Expect(profile).To(Equal(profiles.Profile{
+				SetupLocalRegistry: true,
+				PublishTargets:     []string{},
+				PullPolicy:         profiles.PullIfNotPresent,
+				Sources:            map[string]integration.Source{"nodejs": {Path: "../nodejs"}},
+			}))
+		})
+
+		context("failure cases", func() {
+			it("reports every invalid profile", func() {
+				writeProfiles(`{
+  "default": "dev",
+  "profiles": {
+    "local": {"publish_targets": [], "pull_policy": "sometimes"},
+    "staging": {"registry_url": "localhost:5000", "setup_local_registry": true, "publish_targets": [], "pull_policy": "always"},
+    "compat": {
+      "setup_local_registry": true,
+      "publish_targets": [],
+      "pull_policy": "always",
+      "lifecycles": ["", "https://example.com/lifecycle.tgz", "lifecycles/lifecycle.tgz", "lifecycles/lifecycle.tgz"]
+    }
+  }
+}`)
+
+				_, err := profiles.Load(root)
+				Expect(err).To(MatchError(SatisfyAll(
+					ContainSubstring(`default profile "dev" is not declared`),
+					ContainSubstring("local: one of registry_url or setup_local_registry must be set"),
+					ContainSubstring(`local: pull_policy "sometimes" must be one of`),
+					ContainSubstring("staging: registry_url and setup_local_registry are mutually exclusive"),
+					ContainSubstring("compat: lifecycle 0 must not be empty"),
+					ContainSubstring(`compat: lifecycle "https://example.com/lifecycle.tgz" must be a local archive`),
+					ContainSubstring(`compat: lifecycle "lifecycles/lifecycle.tgz" is listed more than once`),
+				)))
+			})
+		})
+	})
+
+	context("Select", func() {
+		it("fails for unknown profiles", func() {
+			_, _, err := profiles.Profiles{Default: "local", Profiles: map[string]profiles.Profile{"local": {}}}.Select("prod")
+			Expect(err).To(MatchError(`unknown profile "prod", expected one of ["local"]`))
+		})
+	})
+
+	context("CheckReferences", func() {
+		it("reports unknown publish targets and sources", func() {
+			p := profiles.Profiles{
+				Default: "prod",
+				Profiles: map[string]profiles.Profile{
+					"prod": {
+						PublishTargets: []string{"dockerhub", "quay"},
+						Sources:        map[string]integration.Source{"java": {Path: "../java"}},
+					},
+				},
+			}
+
+			err := p.CheckReferences(registries.Registries{Targets: []registries.Target{{Name: "dockerhub"}}}, integration.Config{})
+			Expect(err).To(MatchError(SatisfyAll(
+				ContainSubstring(`prod: publish_targets: unknown targets ["quay"]`),
+				ContainSubstring("prod: sources: java is not a source of integration.json"),
+			)))
+		})
+	})
+
+	context("LifecycleArchives", func() {
+		it("resolves the archives against the root", func() {
+			profile := profiles.Profile{Lifecycles: []string{"lifecycles/lifecycle-v0.17.2.tgz", "/tmp/lifecycle-v0.20.1.tgz"}}
+
+			Expect(profile.LifecycleArchives("/repo")).To(Equal([]string{
+				"/repo/lifecycles/lifecycle-v0.17.2.tgz",
+				"/tmp/lifecycle-v0.20.1.tgz",
+			}))
+		})
+	})
+
+	context("Registry", func() {
+		it("expands the registry of the profile and lets REGISTRY_URL win", func() {
+			t.Setenv("STAGING_REGISTRY_URL", "registry.example.com:5000")
+			profile := profiles.Profile{RegistryURL: "${STAGING_REGISTRY_URL}"}
+			Expect(profile.Registry()).To(Equal("registry.example.com:5000"))
+
+			t.Setenv("REGISTRY_URL", "127.0.0.1:5000")
+			Expect(profile.Registry()).To(Equal("127.0.0.1:5000"))
+		})
+
+		context("failure cases", func() {
+			it("fails when no registry is available", func() {
+				_, err := profiles.Profile{SetupLocalRegistry: true}.Registry()
+				Expect(err).To(MatchError(ContainSubstring("the profile expects a local registry")))
+
+				_, err = profiles.Profile{RegistryURL: "${STAGING_REGISTRY_URL}"}.Registry()
+				Expect(err).To(MatchError(`registry_url "${STAGING_REGISTRY_URL}" expands to an empty string`))
+			})
+		})
+	})
+}
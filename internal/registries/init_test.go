@@ -0,0 +1,14 @@
+package registries_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitRegistries(t *testing.T) {
+	suite := spec.New("registries", spec.Report(report.Terminal{}))
+	suite("Registries", testRegistries)
+	suite.Run(t)
+}
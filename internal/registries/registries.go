@@ -0,0 +1,257 @@
+package registries
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+)
+
+// Filename is the location of the registry settings relative to the
+// repository root.
+const Filename = "registries.json"
+
+type Registries struct {
+	Targets []Target `json:"targets"`
+}
+
+// Target is a registry the stack images are published to. Repository and
+// tags are text/template strings evaluated against a Reference. Disabled
+// targets are never published to, and the publish_targets of a profile
+// narrow the enabled ones further.
+type Target struct {
+	Name        string              `json:"name"`
+	Registry    string              `json:"registry"`
+	Repository  string              `json:"repository"`
+	Tags        []string            `json:"tags"`
+	VariantTags map[string][]string `json:"variant_tags,omitempty"`
+	Enabled     bool                `json:"enabled"`
+}
+
+// Release identifies the release being published.
+type Release struct {
+	// Owner is the GitHub organization of the repository, e.g.
+	// paketo-community.
+	Owner string
+	// Repository is the GitHub repository name, e.g. ubi-base-stack.
+	Repository string
+	Version    string
+}
+
+// Reference is the data the repository and tag templates are evaluated
+// against.
+type Reference struct {
+	Release
+
+	// Org is the owner without dashes, as used for Docker Hub organizations.
+	Org string
+	// RepoName is the repository name without its -stack suffix.
+	RepoName string
+	Variant  string
+	// Kind is either build or run.
+	Kind  string
+	Image string
+}
+
+// Publication is the set of fully qualified references an image of a variant
+// is pushed to.
+type Publication struct {
+	Variant string   `json:"variant"`
+	Kind    string   `json:"kind"`
+	Image   string   `json:"image"`
+	Refs    []string `json:"refs"`
+}
+
+// Load reads the registry settings of the repository at root, rejecting any
+// key that is not part of the model.
+func Load(root string) (Registries, error) {
+	path := filepath.Join(root, Filename)
+	file, err := os.Open(path)
+	if err != nil {
+		return Registries{}, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+
+	var registries Registries
+	err = decoder.Decode(&registries)
+	if err != nil {
+		return Registries{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	err = registries.Validate()
+	if err != nil {
+		return Registries{}, fmt.Errorf("invalid %s: %w", path, err)
+	}
+
+	return registries, nil
+}
+
+// Validate checks that target names are unique and that every template
+// parses.
+func (r Registries) Validate() error {
+	var errs []error
+
+	names := map[string]bool{}
+	for index, target := range r.Targets {
+		if target.Name == "" {
+			errs = append(errs, fmt.Errorf("targets[%d]: name must not be empty", index))
+		} else if names[target.Name] {
+			errs = append(errs, fmt.Errorf("targets[%d]: duplicate name %q", index, target.Name))
+		}
+		names[target.Name] = true
+
+		if target.Registry == "" {
+			errs = append(errs, fmt.Errorf("targets[%d] %q: registry must not be empty", index, target.Name))
+		}
+
+		templates := append([]string{target.Repository}, target.Tags...)
+		for _, tags := range target.VariantTags {
+			templates = append(templates, tags...)
+		}
+
+		for _, text := range templates {
+			_, err := template.New("").Option("missingkey=error").Parse(text)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("targets[%d] %q: %w", index, target.Name, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Select keeps the named targets that are enabled, in registries.json order.
+func (r Registries) Select(names []string) (Registries, error) {
+	selected := map[string]bool{}
+	for _, name := range names {
+		selected[name] = true
+	}
+
+	targets := []Target{}
+	for _, target := range r.Targets {
+		if selected[target.Name] && target.Enabled {
+			targets = append(targets, target)
+		}
+		delete(selected, target.Name)
+	}
+
+	if len(selected) > 0 {
+		var unknown []string
+		for _, name := range names {
+			if selected[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		return Registries{}, fmt.Errorf("unknown targets %q", unknown)
+	}
+
+	return Registries{Targets: targets}, nil
+}
+
+// Plan expands every entry of images.json into the references each enabled
+// target would push it to. Build images are only planned for the entry that
+// creates them, and entries past the publish grace period at now are left
+// out. Variant tags are checked against every entry, so that retiring a
+// variant does not invalidate the targets that still mention it.
+func (r Registries) Plan(imagesJson images.ImagesJson, release Release, now time.Time) ([]Publication, error) {
+	variants := map[string]bool{}
+	for _, stack := range imagesJson.StackImages {
+		variants[stack.Name] = true
+	}
+
+	for _, target := range r.Targets {
+		for variant := range target.VariantTags {
+			if !variants[variant] {
+				return nil, fmt.Errorf("target %q declares tags for unknown variant %q", target.Name, variant)
+			}
+		}
+	}
+
+	var publications []Publication
+	for _, stack := range imagesJson.StackImages {
+		if stack.CheckPublishable(now) != nil {
+			continue
+		}
+
+		if stack.CreateBuildImage {
+			publication, err := r.publication(stack, "build", stack.BuildImage, release)
+			if err != nil {
+				return nil, err
+			}
+			publications = append(publications, publication)
+		}
+
+		publication, err := r.publication(stack, "run", stack.RunImage, release)
+		if err != nil {
+			return nil, err
+		}
+		publications = append(publications, publication)
+	}
+
+	return publications, nil
+}
+
+func (r Registries) publication(stack images.StackImages, kind, image string, release Release) (Publication, error) {
+	reference := Reference{
+		Release:  release,
+		Org:      strings.ReplaceAll(release.Owner, "-", ""),
+		RepoName: strings.TrimSuffix(release.Repository, "-stack"),
+		Variant:  stack.Name,
+		Kind:     kind,
+		Image:    image,
+	}
+
+	// an empty list rather than null when no target is enabled
+	publication := Publication{Variant: stack.Name, Kind: kind, Image: image, Refs: []string{}}
+	for _, target := range r.Targets {
+		if !target.Enabled {
+			continue
+		}
+
+		repository, err := execute(target.Repository, reference)
+		if err != nil {
+			return Publication{}, fmt.Errorf("target %q: %w", target.Name, err)
+		}
+
+		tags := target.Tags
+		if variantTags, ok := target.VariantTags[stack.Name]; ok {
+			tags = variantTags
+		}
+
+		for _, tagTemplate := range tags {
+			tag, err := execute(tagTemplate, reference)
+			if err != nil {
+				return Publication{}, fmt.Errorf("target %q: %w", target.Name, err)
+			}
+
+			publication.Refs = append(publication.Refs, fmt.Sprintf("%s/%s:%s", target.Registry, repository, tag))
+		}
+	}
+
+	return publication, nil
+}
+
+func execute(text string, reference Reference) (string, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	err = tmpl.Execute(buffer, reference)
+	if err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
@@ -0,0 +1,255 @@
+package registries_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/paketo-community/ubi-base-stack/internal/registries"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testRegistries(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		root string
+	)
+
+	it.Before(func() {
+		var err error
+		root, err = os.MkdirTemp("", "root")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(root)).To(Succeed())
+	})
+
+	context("Load", func() {
+		it("reads the publish targets", func() {
+			Expect(os.WriteFile(filepath.Join(root, registries.Filename), []byte(`{
+  "targets": [
+    {
+      "name": "dockerhub",
+      "registry": "docker.io",
+      "repository": "{{.Org}}/{{.Image}}-{{.RepoName}}",
+      "tags": ["{{.Version}}", "latest"],
+      "variant_tags": {"nodejs-20": ["{{.Version}}", "latest", "lts"]},
+      "enabled": true
+    }
+  ]
+}`), 0644)).To(Succeed())
+
+			targets, err := registries.Load(root)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targets).To(Equal(registries.Registries{
+				Targets: []registries.Target{
+					{
+						Name:        "dockerhub",
+						Registry:    "docker.io",
+						Repository:  "{{.Org}}/{{.Image}}-{{.RepoName}}",
+						Tags:        []string{"{{.Version}}", "latest"},
+						VariantTags: map[string][]string{"nodejs-20": {"{{.Version}}", "latest", "lts"}},
+						Enabled:     true,
+					},
+				},
+			}))
+		})
+
+		context("failure cases", func() {
+			it("rejects unknown keys", func() {
+				Expect(os.WriteFile(filepath.Join(root, registries.Filename), []byte(`{"dockerhub": true}`), 0644)).To(Succeed())
+
+				_, err := registries.Load(root)
+				Expect(err).To(MatchError(ContainSubstring(`unknown field "dockerhub"`)))
+			})
+
+			it("reports duplicate names, missing registries and broken templates", func() {
+				Expect(os.WriteFile(filepath.Join(root, registries.Filename), []byte(`{
+  "targets": [
+    {"name": "dockerhub", "registry": "docker.io", "repository": "{{.Org", "tags": []},
+    {"name": "dockerhub", "registry": "", "repository": "org/image", "tags": []}
+  ]
+}`), 0644)).To(Succeed())
+
+				_, err := registries.Load(root)
+				Expect(err).To(MatchError(ContainSubstring(`targets[0] "dockerhub": template`)))
+				Expect(err).To(MatchError(ContainSubstring(`targets[1]: duplicate name "dockerhub"`)))
+				Expect(err).To(MatchError(ContainSubstring(`targets[1] "dockerhub": registry must not be empty`)))
+			})
+		})
+	})
+
+	context("Plan", func() {
+		var (
+			targets    registries.Registries
+			imagesJson images.ImagesJson
+			release    registries.Release
+			now        time.Time
+		)
+
+		it.Before(func() {
+			targets = registries.Registries{
+				Targets: []registries.Target{
+					{
+						Name:        "dockerhub",
+						Registry:    "docker.io",
+						Repository:  "{{.Org}}/{{.Image}}-{{.RepoName}}",
+						Tags:        []string{"{{.Version}}", "latest"},
+						VariantTags: map[string][]string{"nodejs-20": {"{{.Version}}-{{.Variant}}"}},
+						Enabled:     true,
+					},
+					{
+						Name:       "gcr",
+						Registry:   "gcr.io",
+						Repository: "{{.Owner}}/{{.Image}}-{{.RepoName}}",
+						Tags:       []string{"{{.Version}}"},
+						Enabled:    false,
+					},
+				},
+			}
+
+			imagesJson = images.ImagesJson{
+				StackImages: []images.StackImages{
+					{Name: "default", BuildImage: "build", RunImage: "run", CreateBuildImage: true},
+					{Name: "nodejs-20", BuildImage: "build-nodejs-20", RunImage: "run-nodejs-20"},
+				},
+			}
+
+			release = registries.Release{Owner: "paketo-community", Repository: "ubi-base-stack", Version: "1.2.3"}
+			now = time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+		})
+
+		it("expands every image into the refs of the enabled targets", func() {
+			plan, err := targets.Plan(imagesJson, release, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan).To(Equal([]registries.Publication{
+				{
+					Variant: "default",
+					Kind:    "build",
+					Image:   "build",
+					Refs: []string{
+						"docker.io/paketocommunity/build-ubi-base:1.2.3",
+						"docker.io/paketocommunity/build-ubi-base:latest",
+					},
+				},
+				{
+					Variant: "default",
+					Kind:    "run",
+					Image:   "run",
+					Refs: []string{
+						"docker.io/paketocommunity/run-ubi-base:1.2.3",
+						"docker.io/paketocommunity/run-ubi-base:latest",
+					},
+				},
+				{
+					Variant: "nodejs-20",
+					Kind:    "run",
+					Image:   "run-nodejs-20",
+					Refs: []string{
+						"docker.io/paketocommunity/run-nodejs-20-ubi-base:1.2.3-nodejs-20",
+					},
+				},
+			}))
+		})
+
+		it("includes targets once they are enabled", func() {
+			targets.Targets[1].Enabled = true
+
+			plan, err := targets.Plan(imagesJson, release, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan[1].Refs).To(ContainElement("gcr.io/paketo-community/run-ubi-base:1.2.3"))
+		})
+
+		it("leaves disabled targets out of a selection", func() {
+			selected, err := targets.Select([]string{"gcr", "dockerhub"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(selected.Targets).To(HaveLen(1))
+			Expect(selected.Targets[0].Name).To(Equal("dockerhub"))
+		})
+
+		it("plans the selected targets only", func() {
+			targets.Targets[1].Enabled = true
+
+			selected, err := targets.Select([]string{"gcr"})
+			Expect(err).NotTo(HaveOccurred())
+
+			plan, err := selected.Plan(imagesJson, release, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan[1].Refs).To(Equal([]string{"gcr.io/paketo-community/run-ubi-base:1.2.3"}))
+
+			selected, err = targets.Select([]string{"gcr", "dockerhub"})
+			Expect(err).NotTo(HaveOccurred())
+
+			plan, err = selected.Plan(imagesJson, release, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan[1].Refs).To(Equal([]string{
+				"docker.io/paketocommunity/run-ubi-base:1.2.3",
+				"docker.io/paketocommunity/run-ubi-base:latest",
+				"gcr.io/paketo-community/run-ubi-base:1.2.3",
+			}))
+
+		})
+
+		it("plans empty refs for a profile without targets", func() {
+			selected, err := targets.Select([]string{})
+			Expect(err).NotTo(HaveOccurred())
+
+			plan, err := selected.Plan(imagesJson, release, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan).To(HaveLen(3))
+
+			content, err := json.Marshal(plan[1])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal(`{"variant":"default","kind":"run","image":"run","refs":[]}`))
+		})
+
+		it("leaves out retired variants while accepting their variant tags", func() {
+			imagesJson.StackImages = append(imagesJson.StackImages, images.StackImages{
+				Name:      "nodejs-16",
+				RunImage:  "run-nodejs-16",
+				Lifecycle: &images.Lifecycle{SupportedUntil: "2023-09-11"},
+			})
+			targets.Targets[0].VariantTags["nodejs-16"] = []string{"{{.Version}}-{{.Variant}}"}
+
+			plan, err := targets.Plan(imagesJson, release, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan).To(HaveLen(3))
+			for _, publication := range plan {
+				Expect(publication.Variant).NotTo(Equal("nodejs-16"))
+			}
+
+			plan, err = targets.Plan(imagesJson, release, time.Date(2023, time.December, 10, 0, 0, 0, 0, time.UTC))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan).To(HaveLen(4))
+			Expect(plan[3].Refs).To(Equal([]string{"docker.io/paketocommunity/run-nodejs-16-ubi-base:1.2.3-nodejs-16"}))
+		})
+
+		context("failure cases", func() {
+			it("rejects unknown targets in a selection", func() {
+				_, err := targets.Select([]string{"dockerhub", "quay"})
+				Expect(err).To(MatchError(`unknown targets ["quay"]`))
+			})
+
+			it("rejects tags for unknown variants", func() {
+				targets.Targets[0].VariantTags = map[string][]string{"nodejs-22": {"latest"}}
+
+				_, err := targets.Plan(imagesJson, release, now)
+				Expect(err).To(MatchError(`target "dockerhub" declares tags for unknown variant "nodejs-22"`))
+			})
+
+			it("reports templates referencing unknown fields", func() {
+				targets.Targets[0].Tags = []string{"{{.Tag}}"}
+
+				_, err := targets.Plan(imagesJson, release, now)
+				Expect(err).To(MatchError(ContainSubstring(`target "dockerhub"`)))
+			})
+		})
+	})
+}
@@ -0,0 +1,49 @@
+package schema
+
+import (
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/paketo-community/ubi-base-stack/internal/integration"
+	"github.com/paketo-community/ubi-base-stack/internal/profiles"
+	"github.com/paketo-community/ubi-base-stack/internal/registries"
+)
+
+// Dir is the directory, relative to the repository root, in which the
+// generated schemas are published.
+const Dir = "schemas"
+
+const baseURL = "https://raw.githubusercontent.com/paketo-community/ubi-base-stack/main/schemas/"
+
+// Document pairs a hand-edited configuration file with the schema of the Go
+// type that models it.
+type Document struct {
+	Path       string
+	SchemaFile string
+	Schema     *Schema
+}
+
+// Documents returns every configuration file of the repository that has a
+// published schema.
+func Documents() []Document {
+	return []Document{
+		{
+			Path:       images.Filename,
+			SchemaFile: "images.schema.json",
+			Schema:     Generate(images.ImagesJson{}, baseURL+"images.schema.json", "Stack images"),
+		},
+		{
+			Path:       integration.Filename,
+			SchemaFile: "integration.schema.json",
+			Schema:     Generate(integration.Config{}, baseURL+"integration.schema.json", "Integration test settings"),
+		},
+		{
+			Path:       registries.Filename,
+			SchemaFile: "registries.schema.json",
+			Schema:     Generate(registries.Registries{}, baseURL+"registries.schema.json", "Publish registries"),
+		},
+		{
+			Path:       profiles.Filename,
+			SchemaFile: "profiles.schema.json",
+			Schema:     Generate(profiles.Profiles{}, baseURL+"profiles.schema.json", "Environment profiles"),
+		},
+	}
+}
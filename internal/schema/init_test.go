@@ -0,0 +1,15 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitSchema(t *testing.T) {
+	suite := spec.New("schema", spec.Report(report.Terminal{}))
+	suite("Schema", testSchema)
+	suite("Validate", testValidate)
+	suite.Run(t)
+}
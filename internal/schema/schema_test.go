@@ -0,0 +1,57 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/paketo-community/ubi-base-stack/internal/schema"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testSchema(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("Generate", func() {
+		it("derives properties and required keys from json tags", func() {
+			type entry struct {
+				Name     string            `json:"name"`
+				Optional bool              `json:"optional,omitempty"`
+				Count    int               `json:"count"`
+				Tags     []string          `json:"tags,omitempty"`
+				Args     map[string]string `json:"args,omitempty"`
+				ignored  string
+			}
+
+			s := schema.Generate(struct {
+				Entries []entry `json:"entries"`
+			}{}, "https://example.com/test.schema.json", "Test")
+
+			Expect(s.Draft).To(Equal(schema.Draft))
+			Expect(s.ID).To(Equal("https://example.com/test.schema.json"))
+			Expect(s.Title).To(Equal("Test"))
+			Expect(s.Required).To(Equal([]string{"entries"}))
+			Expect(s.AdditionalProperties).To(Equal(false))
+
+			items := s.Properties["entries"].Items
+			Expect(items.Type).To(Equal("object"))
+			Expect(items.Required).To(Equal([]string{"count", "name"}))
+			Expect(items.Properties).To(HaveLen(5))
+			Expect(items.Properties["optional"].Type).To(Equal("boolean"))
+			Expect(items.Properties["count"].Type).To(Equal("integer"))
+			Expect(items.Properties["tags"].Items.Type).To(Equal("string"))
+			Expect(items.Properties["args"].AdditionalProperties).To(Equal(&schema.Schema{Type: "string"}))
+		})
+	})
+
+	context("Documents", func() {
+		it("covers every hand-edited configuration file", func() {
+			var paths []string
+			for _, document := range schema.Documents() {
+				paths = append(paths, document.Path)
+			}
+
+			Expect(paths).To(ConsistOf("stacks/images.json", "integration.json", "registries.json", "profiles.json"))
+		})
+	})
+}
@@ -0,0 +1,234 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Error is a schema violation located in the validated document.
+type Error struct {
+	Line    int
+	Column  int
+	Path    string
+	Message string
+}
+
+func (e Error) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// node is a JSON value annotated with the offset at which it starts.
+type node struct {
+	offset  int
+	kind    string
+	members []member
+	items   []*node
+}
+
+type member struct {
+	key    string
+	offset int
+	value  *node
+}
+
+// Validate checks content against s and returns every violation it finds,
+// ordered by position. A document that is not well-formed JSON yields a
+// single error.
+func Validate(s *Schema, content []byte) []Error {
+	p := parser{content: content, decoder: json.NewDecoder(bytes.NewReader(content))}
+	p.decoder.UseNumber()
+
+	root, err := p.parse()
+	if err == nil && p.decoder.More() {
+		err = p.errorf(p.next(), "unexpected content after the top-level value")
+	}
+	if err != nil {
+		var schemaErr Error
+		if errors.As(err, &schemaErr) {
+			return []Error{schemaErr}
+		}
+		offset := int(p.decoder.InputOffset())
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			offset = int(syntaxErr.Offset)
+		}
+		line, column := p.position(offset)
+		return []Error{{Line: line, Column: column, Message: err.Error()}}
+	}
+
+	var errs []Error
+	p.validate(s, root, "", &errs)
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Line != errs[j].Line {
+			return errs[i].Line < errs[j].Line
+		}
+		return errs[i].Column < errs[j].Column
+	})
+
+	return errs
+}
+
+type parser struct {
+	content []byte
+	decoder *json.Decoder
+}
+
+// next returns the offset at which the next token starts.
+func (p parser) next() int {
+	offset := int(p.decoder.InputOffset())
+	for offset < len(p.content) && strings.ContainsRune(" \t\r\n:,", rune(p.content[offset])) {
+		offset++
+	}
+	return offset
+}
+
+func (p parser) position(offset int) (line int, column int) {
+	line, column = 1, 1
+	for _, b := range p.content[:min(offset, len(p.content))] {
+		if b == '\n' {
+			line++
+			column = 1
+			continue
+		}
+		column++
+	}
+	return line, column
+}
+
+func (p parser) errorf(offset int, format string, args ...any) Error {
+	line, column := p.position(offset)
+	return Error{Line: line, Column: column, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p parser) parse() (*node, error) {
+	offset := p.next()
+	token, err := p.decoder.Token()
+	if err != nil {
+		if err == io.EOF {
+			return nil, p.errorf(offset, "unexpected end of document")
+		}
+		return nil, err
+	}
+
+	n := &node{offset: offset}
+
+	switch value := token.(type) {
+	case json.Delim:
+		switch value {
+		case '{':
+			n.kind = "object"
+			for p.decoder.More() {
+				keyOffset := p.next()
+				key, err := p.decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				child, err := p.parse()
+				if err != nil {
+					return nil, err
+				}
+
+				n.members = append(n.members, member{key: key.(string), offset: keyOffset, value: child})
+			}
+
+		case '[':
+			n.kind = "array"
+			for p.decoder.More() {
+				child, err := p.parse()
+				if err != nil {
+					return nil, err
+				}
+				n.items = append(n.items, child)
+			}
+		}
+
+		// consume the closing delimiter
+		_, err = p.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+	case string:
+		n.kind = "string"
+
+	case bool:
+		n.kind = "boolean"
+
+	case json.Number:
+		n.kind = "number"
+		if _, err := value.Int64(); err == nil {
+			n.kind = "integer"
+		}
+
+	case nil:
+		n.kind = "null"
+	}
+
+	return n, nil
+}
+
+func (p parser) validate(s *Schema, n *node, path string, errs *[]Error) {
+	fail := func(offset int, path string, format string, args ...any) {
+		err := p.errorf(offset, format, args...)
+		err.Path = path
+		*errs = append(*errs, err)
+	}
+
+	if s.Type != "" && s.Type != n.kind && !(s.Type == "number" && n.kind == "integer") {
+		fail(n.offset, path, "expected %s, found %s", s.Type, n.kind)
+		return
+	}
+
+	switch n.kind {
+	case "object":
+		present := map[string]bool{}
+		for _, m := range n.members {
+			present[m.key] = true
+			memberPath := join(path, m.key)
+
+			if property, ok := s.Properties[m.key]; ok {
+				p.validate(property, m.value, memberPath, errs)
+				continue
+			}
+
+			switch additional := s.AdditionalProperties.(type) {
+			case bool:
+				if !additional {
+					fail(m.offset, path, "unknown property %q", m.key)
+				}
+			case *Schema:
+				p.validate(additional, m.value, memberPath, errs)
+			}
+		}
+
+		for _, name := range s.Required {
+			if !present[name] {
+				fail(n.offset, path, "missing required property %q", name)
+			}
+		}
+
+	case "array":
+		if s.Items == nil {
+			return
+		}
+		for i, item := range n.items {
+			p.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,68 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/paketo-community/ubi-base-stack/internal/schema"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testValidate(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		s *schema.Schema
+	)
+
+	it.Before(func() {
+		type image struct {
+			Name             string `json:"name"`
+			CreateBuildImage bool   `json:"create_build_image,omitempty"`
+		}
+
+		s = schema.Generate(struct {
+			ReceiptsShowLimit int     `json:"receipts_show_limit"`
+			Images            []image `json:"images"`
+		}{}, "", "")
+	})
+
+	it("accepts a conforming document", func() {
+		Expect(schema.Validate(s, []byte(`{"receipts_show_limit": 16, "images": [{"name": "default"}]}`))).To(BeEmpty())
+	})
+
+	it("reports violations with their line and column", func() {
+		errs := schema.Validate(s, []byte(`{
+  "receipts_show_limit": "16",
+  "images": [
+    {
+      "name": "default",
+      "create_build_imag": true
+    },
+    {}
+  ]
+}`))
+
+		Expect(errs).To(Equal([]schema.Error{
+			{Line: 2, Column: 26, Path: "receipts_show_limit", Message: "expected integer, found string"},
+			{Line: 6, Column: 7, Path: "images[0]", Message: `unknown property "create_build_imag"`},
+			{Line: 8, Column: 5, Path: "images[1]", Message: `missing required property "name"`},
+		}))
+		Expect(errs[1].Error()).To(Equal(`6:7: images[0]: unknown property "create_build_imag"`))
+	})
+
+	it("reports malformed documents", func() {
+		errs := schema.Validate(s, []byte("{\n  \"images\": [,]\n}"))
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Line).To(Equal(2))
+	})
+
+	it("reports trailing content", func() {
+		errs := schema.Validate(s, []byte(`{"receipts_show_limit": 1, "images": []}
+{}`))
+		Expect(errs).To(Equal([]schema.Error{
+			{Line: 2, Column: 1, Message: "unexpected content after the top-level value"},
+		}))
+	})
+}
@@ -0,0 +1,313 @@
+package utils
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// Archive is an extracted OCI archive, a tarball of an OCI image layout as
+// written by jam create-stack.
+type Archive struct {
+	// Index lists the images of the archive, one per platform.
+	Index v1.ImageIndex
+
+	dir string
+}
+
+// OpenArchive extracts the OCI archive at path into a temporary directory,
+// which Close removes. Images read from the archive are only valid until
+// then.
+func OpenArchive(path string) (Archive, error) {
+	dir, err := os.MkdirTemp("", "oci-archive")
+	if err != nil {
+		return Archive{}, err
+	}
+
+	archive := Archive{dir: dir}
+
+	err = extract(path, dir)
+	if err != nil {
+		archive.Close()
+		return Archive{}, fmt.Errorf("failed to extract %s: %w", path, err)
+	}
+
+	archive.Index, err = layout.ImageIndexFromPath(dir)
+	if err != nil {
+		archive.Close()
+		return Archive{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	// the layout index may wrap the multi-platform index of the image
+	manifest, err := archive.Index.IndexManifest()
+	if err != nil {
+		archive.Close()
+		return Archive{}, err
+	}
+
+	if len(manifest.Manifests) == 1 && manifest.Manifests[0].MediaType.IsIndex() {
+		archive.Index, err = archive.Index.ImageIndex(manifest.Manifests[0].Digest)
+		if err != nil {
+			archive.Close()
+			return Archive{}, err
+		}
+	}
+
+	return archive, nil
+}
+
+// Image returns the image of the archive for platform.
+func (a Archive) Image(platform v1.Platform) (v1.Image, error) {
+	return imageForPlatform(a.Index, platform)
+}
+
+// Close removes the extracted archive.
+func (a Archive) Close() error {
+	return os.RemoveAll(a.dir)
+}
+
+// WriteArchive writes index as an OCI archive that OpenArchive and jam
+// publish-image can read.
+func WriteArchive(path string, index v1.ImageIndex) error {
+	dir, err := os.MkdirTemp("", "oci-archive")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	_, err = layout.Write(dir, index)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := tar.NewWriter(file)
+	err = filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+
+		err = writer.WriteHeader(header)
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		content, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer content.Close()
+
+		_, err = io.Copy(writer, content)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+// SinglePlatformIndex wraps image in an index that records its platform.
+func SinglePlatformIndex(image v1.Image) (v1.ImageIndex, error) {
+	config, err := image.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return mutate.AppendManifests(empty.Index, mutate.IndexAddendum{
+		Add: image,
+		Descriptor: v1.Descriptor{
+			Platform: config.Platform(),
+		},
+	}), nil
+}
+
+// PushArchive pushes every image of the OCI archive at path to ref.
+func PushArchive(path string, ref string) error {
+	reference, err := name.ParseReference(ref)
+	if err != nil {
+		return err
+	}
+
+	archive, err := OpenArchive(path)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	err = remote.WriteIndex(reference, archive.Index, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return fmt.Errorf("failed to push %s to %s: %w", path, ref, err)
+	}
+
+	return nil
+}
+
+// PublishImage pushes image to ref.
+func PublishImage(ref string, image v1.Image) error {
+	reference, err := name.ParseReference(ref)
+	if err != nil {
+		return err
+	}
+
+	err = remote.Write(reference, image, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return fmt.Errorf("failed to push %s: %w", ref, err)
+	}
+
+	return nil
+}
+
+// PublishIndex pushes index to ref.
+func PublishIndex(ref string, index v1.ImageIndex) error {
+	reference, err := name.ParseReference(ref)
+	if err != nil {
+		return err
+	}
+
+	err = remote.WriteIndex(reference, index, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return fmt.Errorf("failed to push %s: %w", ref, err)
+	}
+
+	return nil
+}
+
+// ParsePlatform parses an os/arch[/variant] platform, defaulting to the
+// platform of the host for an empty string.
+func ParsePlatform(value string) (v1.Platform, error) {
+	if value == "" {
+		return v1.Platform{OS: "linux", Architecture: runtime.GOARCH}, nil
+	}
+
+	platform, err := v1.ParsePlatform(value)
+	if err != nil {
+		return v1.Platform{}, err
+	}
+
+	if platform.OS == "" || platform.Architecture == "" {
+		return v1.Platform{}, fmt.Errorf("platform %q must be of the form os/arch[/variant]", value)
+	}
+
+	return *platform, nil
+}
+
+func imageForPlatform(index v1.ImageIndex, platform v1.Platform) (v1.Image, error) {
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	var available []string
+	for _, descriptor := range manifest.Manifests {
+		if descriptor.Platform == nil {
+			continue
+		}
+
+		if descriptor.Platform.Satisfies(platform) {
+			return index.Image(descriptor.Digest)
+		}
+		available = append(available, descriptor.Platform.String())
+	}
+
+	return nil, fmt.Errorf("no image for platform %s, found %q", platform.String(), available)
+}
+
+func extract(path, dir string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := tar.NewReader(file)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) || filepath.IsAbs(name) {
+			return fmt.Errorf("entry %q escapes the archive", header.Name)
+		}
+		target := filepath.Join(dir, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, os.ModePerm)
+		case tar.TypeReg:
+			err = writeFile(target, reader)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func writeFile(path string, content io.Reader) error {
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, content)
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
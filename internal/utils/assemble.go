@@ -0,0 +1,1069 @@
+package utils
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// DockerTransport prefixes the URI of modules shipped as buildpackage images.
+const DockerTransport = "docker://"
+
+// LifecycleReleaseURL is where the go assembler downloads lifecycle versions
+// from, formatted with the version and the lifecycle name of the
+// architecture.
+const LifecycleReleaseURL = "https://github.com/buildpacks/lifecycle/releases/download/v%[1]s/lifecycle-v%[1]s+linux.%[2]s.tgz"
+
+// normalizedTime is the modification time of the files and layers the
+// assembler writes, as pack does, so that unchanged inputs produce the same
+// builder digest.
+var normalizedTime = time.Date(1980, time.January, 1, 0, 0, 1, 0, time.UTC)
+
+type moduleKind struct {
+	name        string
+	descriptor  string
+	dir         string
+	layersLabel string
+}
+
+var (
+	buildpackKind = moduleKind{
+		name:        "buildpack",
+		descriptor:  "buildpack.toml",
+		dir:         "cnb/buildpacks",
+		layersLabel: BuildpackLayersLabel,
+	}
+	extensionKind = moduleKind{
+		name:        "extension",
+		descriptor:  "extension.toml",
+		dir:         "cnb/extensions",
+		layersLabel: ExtensionLayersLabel,
+	}
+)
+
+// module is one version of a buildpack or extension along with its layer.
+type module struct {
+	info  ModuleInfo
+	layer ModuleLayer
+	blob  v1.Layer
+}
+
+type moduleDescriptor struct {
+	API       string         `toml:"api"`
+	Buildpack moduleIdentity `toml:"buildpack"`
+	Extension moduleIdentity `toml:"extension"`
+	Stacks    []ModuleStack  `toml:"stacks"`
+	Targets   []Target       `toml:"targets"`
+	Order     []OrderTable   `toml:"order"`
+}
+
+type moduleIdentity struct {
+	ID       string `toml:"id"`
+	Version  string `toml:"version"`
+	Name     string `toml:"name"`
+	Homepage string `toml:"homepage"`
+}
+
+type lifecycleDescriptor struct {
+	APIs      LifecycleAPIs `toml:"apis"`
+	Lifecycle struct {
+		Version string `toml:"version"`
+	} `toml:"lifecycle"`
+}
+
+// BaseDigestAnnotation records the digest of the build image a builder is
+// created from in the manifest of the builder.
+const BaseDigestAnnotation = "org.opencontainers.image.base.digest"
+
+// AssembleBuilderIndex creates a builder for every platform of options on
+// top of the build image of the same platform of build, and verifies the
+// resulting index.
+func AssembleBuilderIndex(build v1.ImageIndex, options BuilderOptions) (v1.ImageIndex, error) {
+	platforms, err := options.platforms()
+	if err != nil {
+		return nil, err
+	}
+
+	var addenda []mutate.IndexAddendum
+	for _, platform := range platforms {
+		image, err := imageForPlatform(build, platform)
+		if err != nil {
+			return nil, fmt.Errorf("build image: %w", err)
+		}
+
+		builder, err := AssembleBuilder(image, options)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", platform.String(), err)
+		}
+
+		platform := platform
+		addenda = append(addenda, mutate.IndexAddendum{
+			Add:        builder,
+			Descriptor: v1.Descriptor{Platform: &platform},
+		})
+	}
+
+	index := mutate.AppendManifests(mutate.IndexMediaType(empty.Index, types.OCIImageIndex), addenda...)
+
+	err = VerifyBuilder(index, build, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
+
+// AssembleBuilder creates the builder described by options in process, on
+// top of build, for the platform of build. The run images of the options
+// must be references. Modules are buildpack directories, tarballs of one or
+// docker:// buildpackage images. Unlike pack, the lifecycle must be pinned.
+func AssembleBuilder(build v1.Image, options BuilderOptions) (v1.Image, error) {
+	if options.Lifecycle == (BuilderLifecycle{}) {
+		return nil, errors.New("builder options: the go assembler requires a lifecycle version or uri")
+	}
+
+	config, err := build.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	platform := v1.Platform{OS: config.OS, Architecture: config.Architecture, Variant: config.Variant}
+
+	target, err := checkTargets(options.Targets, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	runImages, err := options.runImages()
+	if err != nil {
+		return nil, err
+	}
+
+	if id := config.Config.Labels[StackIDLabel]; options.StackID != "" && id != options.StackID {
+		return nil, fmt.Errorf("the build image has stack id %q, expected %q", id, options.StackID)
+	}
+
+	uid, gid, err := cnbUser(config.Config.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaType, err := layerMediaType(build)
+	if err != nil {
+		return nil, err
+	}
+
+	lifecycle, lifecycleLayer, err := lifecycleLayer(options.Lifecycle, platform, mediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	buildpacks, err := loadModules(buildpackKind, options.Buildpacks, platform, mediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	extensions, err := loadModules(extensionKind, options.Extensions, platform, mediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, m := range append(append([]module{}, buildpacks...), extensions...) {
+		errs = append(errs, checkCompatibility(m, options.StackID, target))
+	}
+
+	order, err := resolveOrder("order", options.Order, buildpacks)
+	errs = append(errs, err)
+
+	orderExtensions, err := resolveOrder("order-extensions", options.OrderExtensions, extensions)
+	errs = append(errs, err)
+
+	err = errors.Join(errs...)
+	if err != nil {
+		return nil, err
+	}
+
+	workspace := newLayerBuilder()
+	workspace.dir("workspace", uid, gid)
+	workspace.dir("layers", uid, gid)
+	workspace.dir(buildpackKind.dir, 0, 0)
+	if len(extensions) > 0 {
+		workspace.dir(extensionKind.dir, 0, 0)
+	}
+	workspace.dir("platform/env", 0, 0)
+
+	orderToml, err := encodeToml(struct {
+		Order           []OrderTable `toml:"order"`
+		OrderExtensions []OrderTable `toml:"order-extensions,omitempty"`
+	}{
+		Order:           order,
+		OrderExtensions: orderExtensions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	runToml, err := encodeToml(struct {
+		Images []RunImageMetadata `toml:"images"`
+	}{
+		Images: runImages,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// stack.toml serves lifecycles that predate run.toml
+	stackToml, err := encodeToml(struct {
+		RunImage RunImageMetadata `toml:"run-image"`
+	}{
+		RunImage: runImages[0],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	descriptors := newLayerBuilder()
+	descriptors.file("cnb/order.toml", 0644, orderToml)
+	descriptors.file("cnb/run.toml", 0644, runToml)
+	descriptors.file("cnb/stack.toml", 0644, stackToml)
+
+	addenda := []mutate.Addendum{}
+	add := func(layer *layerBuilder, createdBy string) error {
+		blob, err := layer.layer(mediaType)
+		if err != nil {
+			return err
+		}
+		addenda = append(addenda, addendum(blob, createdBy))
+		return nil
+	}
+
+	err = add(workspace, "stack-tools: workspace")
+	if err != nil {
+		return nil, err
+	}
+	addenda = append(addenda, addendum(lifecycleLayer, fmt.Sprintf("stack-tools: lifecycle %s", lifecycle.Version)))
+	for _, m := range append(append([]module{}, buildpacks...), extensions...) {
+		addenda = append(addenda, addendum(m.blob, fmt.Sprintf("stack-tools: %s@%s", m.info.ID, m.info.Version)))
+	}
+	err = add(descriptors, "stack-tools: order and run images")
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := BuilderMetadata{
+		Description: options.Description,
+		Stack: StackMetadata{
+			RunImage: runImages[0],
+		},
+		Images:     runImages,
+		Buildpacks: infos(buildpacks),
+		Extensions: infos(extensions),
+		Lifecycle:  lifecycle,
+		CreatedBy:  creator(),
+	}
+
+	labels := map[string]string{}
+	for key, value := range config.Config.Labels {
+		labels[key] = value
+	}
+	for key, value := range options.Labels {
+		labels[key] = value
+	}
+
+	values := map[string]any{
+		BuilderMetadataLabel: metadata,
+		BuildpackLayersLabel: moduleLayers(buildpacks),
+		BuildpackOrderLabel:  order,
+	}
+	if len(extensions) > 0 {
+		values[ExtensionLayersLabel] = moduleLayers(extensions)
+		values[ExtensionOrderLabel] = orderExtensions
+	}
+	for key, value := range values {
+		content, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		labels[key] = string(content)
+	}
+
+	builder, err := mutate.Append(build, addenda...)
+	if err != nil {
+		return nil, err
+	}
+
+	builderConfig := *config.Config.DeepCopy()
+	builderConfig.Labels = labels
+	builderConfig.WorkingDir = "/layers"
+
+	builder, err = mutate.Config(builder, builderConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	builder, err = mutate.CreatedAt(builder, v1.Time{Time: normalizedTime})
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := build.Digest()
+	if err != nil {
+		return nil, err
+	}
+
+	return mutate.Annotations(builder, map[string]string{BaseDigestAnnotation: digest.String()}).(v1.Image), nil
+}
+
+func cnbUser(env []string) (int, int, error) {
+	ids := map[string]int{}
+	for _, variable := range env {
+		key, value, _ := strings.Cut(variable, "=")
+		if key != "CNB_USER_ID" && key != "CNB_GROUP_ID" {
+			continue
+		}
+
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("the build image sets an invalid %s: %w", key, err)
+		}
+		ids[key] = id
+	}
+
+	for _, key := range []string{"CNB_USER_ID", "CNB_GROUP_ID"} {
+		if _, ok := ids[key]; !ok {
+			return 0, 0, fmt.Errorf("the build image does not set %s", key)
+		}
+	}
+
+	return ids["CNB_USER_ID"], ids["CNB_GROUP_ID"], nil
+}
+
+func layerMediaType(image v1.Image) (types.MediaType, error) {
+	mediaType, err := image.MediaType()
+	if err != nil {
+		return "", err
+	}
+
+	if mediaType == types.OCIManifestSchema1 {
+		return types.OCILayer, nil
+	}
+
+	return types.DockerLayer, nil
+}
+
+func addendum(layer v1.Layer, createdBy string) mutate.Addendum {
+	return mutate.Addendum{
+		Layer: layer,
+		History: v1.History{
+			Created:   v1.Time{Time: normalizedTime},
+			CreatedBy: createdBy,
+		},
+	}
+}
+
+func lifecycleLayer(lifecycle BuilderLifecycle, platform v1.Platform, mediaType types.MediaType) (LifecycleMetadata, v1.Layer, error) {
+	uri, err := lifecycleURI(lifecycle, platform)
+	if err != nil {
+		return LifecycleMetadata{}, nil, err
+	}
+
+	entries, err := readURI(uri)
+	if err != nil {
+		return LifecycleMetadata{}, nil, fmt.Errorf("failed to read lifecycle %s: %w", uri, err)
+	}
+
+	var descriptor *lifecycleDescriptor
+	layer := newLayerBuilder()
+	for _, entry := range entries {
+		if entry.header.Name == "lifecycle.toml" {
+			descriptor = &lifecycleDescriptor{}
+			_, err = toml.Decode(string(entry.content), descriptor)
+			if err != nil {
+				return LifecycleMetadata{}, nil, fmt.Errorf("%s: failed to parse lifecycle.toml: %w", uri, err)
+			}
+			continue
+		}
+
+		if strings.HasPrefix(entry.header.Name, "lifecycle/") {
+			layer.add(path.Join("cnb", entry.header.Name), entry)
+		}
+	}
+
+	if descriptor == nil {
+		return LifecycleMetadata{}, nil, fmt.Errorf("%s: no lifecycle.toml found", uri)
+	}
+
+	if lifecycle.Version != "" && descriptor.Lifecycle.Version != lifecycle.Version {
+		return LifecycleMetadata{}, nil, fmt.Errorf("%s: found lifecycle %s, expected %s", uri, descriptor.Lifecycle.Version, lifecycle.Version)
+	}
+
+	metadata := LifecycleMetadata{
+		Version: descriptor.Lifecycle.Version,
+		APIs:    descriptor.APIs,
+	}
+	if supported := descriptor.APIs.Buildpack.Supported; len(supported) > 0 {
+		metadata.API.BuildpackVersion = supported[0]
+	}
+	if supported := descriptor.APIs.Platform.Supported; len(supported) > 0 {
+		metadata.API.PlatformVersion = supported[0]
+	}
+
+	blob, err := layer.layer(mediaType)
+	if err != nil {
+		return LifecycleMetadata{}, nil, err
+	}
+
+	return metadata, blob, nil
+}
+
+// lifecycleURI returns the archive of lifecycle for platform, the release of
+// its version or its URI with LifecycleArchPlaceholder expanded.
+func lifecycleURI(lifecycle BuilderLifecycle, platform v1.Platform) (string, error) {
+	arch := map[string]string{
+		"amd64":   "x86-64",
+		"arm64":   "arm64",
+		"ppc64le": "ppc64le",
+		"s390x":   "s390x",
+	}[platform.Architecture]
+
+	uri := lifecycle.URI
+	if uri == "" || strings.Contains(uri, LifecycleArchPlaceholder) {
+		if arch == "" {
+			return "", fmt.Errorf("no lifecycle release for platform %s", platform.String())
+		}
+	}
+
+	if uri == "" {
+		uri = fmt.Sprintf(LifecycleReleaseURL, lifecycle.Version, arch)
+	}
+
+	return strings.ReplaceAll(uri, LifecycleArchPlaceholder, arch), nil
+}
+
+// loadModules reads the modules of kind, sorted by id and version. Modules
+// that several sources provide are only added once.
+func loadModules(kind moduleKind, sources []BuilderModule, platform v1.Platform, mediaType types.MediaType) ([]module, error) {
+	var (
+		modules []module
+		errs    []error
+	)
+
+	seen := map[string]bool{}
+	for _, source := range sources {
+		var (
+			loaded []module
+			err    error
+		)
+		if strings.HasPrefix(source.URI, DockerTransport) {
+			loaded, err = loadPackage(kind, source, platform)
+		} else {
+			loaded, err = loadModule(kind, source, mediaType)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", kind.name, source.URI, err))
+			continue
+		}
+
+		for _, m := range loaded {
+			key := m.info.ID + "@" + m.info.Version
+			if !seen[key] {
+				seen[key] = true
+				modules = append(modules, m)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	sort.Slice(modules, func(i, j int) bool {
+		if modules[i].info.ID != modules[j].info.ID {
+			return modules[i].info.ID < modules[j].info.ID
+		}
+		return modules[i].info.Version < modules[j].info.Version
+	})
+
+	return modules, nil
+}
+
+// loadModule reads a module from a directory or a tarball with the module
+// descriptor at its root.
+func loadModule(kind moduleKind, source BuilderModule, mediaType types.MediaType) ([]module, error) {
+	entries, err := readURI(source.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	var descriptor *moduleDescriptor
+	for _, entry := range entries {
+		if entry.header.Name == kind.descriptor {
+			descriptor = &moduleDescriptor{}
+			_, err = toml.Decode(string(entry.content), descriptor)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", kind.descriptor, err)
+			}
+		}
+	}
+
+	if descriptor == nil {
+		return nil, fmt.Errorf("no %s found", kind.descriptor)
+	}
+
+	identity := descriptor.Buildpack
+	if kind == extensionKind {
+		identity = descriptor.Extension
+	}
+
+	if identity.ID == "" || identity.Version == "" {
+		return nil, fmt.Errorf("%s must declare an id and a version", kind.descriptor)
+	}
+
+	err = checkIdentity(source, identity.ID, identity.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := path.Join(kind.dir, strings.ReplaceAll(identity.ID, "/", "_"), identity.Version)
+
+	layer := newLayerBuilder()
+	for _, entry := range entries {
+		layer.add(path.Join(dir, entry.header.Name), entry)
+	}
+
+	blob, err := layer.layer(mediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	diffID, err := blob.DiffID()
+	if err != nil {
+		return nil, err
+	}
+
+	return []module{{
+		info: ModuleInfo{
+			ID:       identity.ID,
+			Version:  identity.Version,
+			Name:     identity.Name,
+			Homepage: identity.Homepage,
+		},
+		layer: ModuleLayer{
+			API:         descriptor.API,
+			Stacks:      descriptor.Stacks,
+			Targets:     descriptor.Targets,
+			Order:       descriptor.Order,
+			LayerDiffID: diffID.String(),
+			Homepage:    identity.Homepage,
+			Name:        identity.Name,
+		},
+		blob: blob,
+	}}, nil
+}
+
+// loadPackage reads every module a buildpackage image ships, reusing its
+// layers as they are.
+func loadPackage(kind moduleKind, source BuilderModule, platform v1.Platform) ([]module, error) {
+	reference, err := name.ParseReference(strings.TrimPrefix(source.URI, DockerTransport))
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := remote.Image(reference, remote.WithPlatform(platform), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := image.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	var top ModuleInfo
+	err = json.Unmarshal([]byte(config.Config.Labels[BuildpackageLabel]), &top)
+	if err != nil {
+		return nil, fmt.Errorf("not a buildpackage, failed to parse its %s label: %w", BuildpackageLabel, err)
+	}
+
+	err = checkIdentity(source, top.ID, top.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	var layers ModuleLayers
+	err = json.Unmarshal([]byte(config.Config.Labels[kind.layersLabel]), &layers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse its %s label: %w", kind.layersLabel, err)
+	}
+
+	var modules []module
+	for id, versions := range layers {
+		for version, layer := range versions {
+			diffID, err := v1.NewHash(layer.LayerDiffID)
+			if err != nil {
+				return nil, fmt.Errorf("%s@%s: %w", id, version, err)
+			}
+
+			blob, err := image.LayerByDiffID(diffID)
+			if err != nil {
+				return nil, fmt.Errorf("%s@%s: %w", id, version, err)
+			}
+
+			info := ModuleInfo{ID: id, Version: version, Name: layer.Name, Homepage: layer.Homepage}
+			if id == top.ID && version == top.Version && info.Homepage == "" {
+				info.Homepage = top.Homepage
+			}
+
+			modules = append(modules, module{info: info, layer: layer, blob: blob})
+		}
+	}
+
+	return modules, nil
+}
+
+func checkIdentity(source BuilderModule, id, version string) error {
+	if source.ID != "" && source.ID != id {
+		return fmt.Errorf("found %s, expected %s", id, source.ID)
+	}
+
+	if source.Version != "" && source.Version != version {
+		return fmt.Errorf("found %s@%s, expected version %s", id, version, source.Version)
+	}
+
+	return nil
+}
+
+// checkCompatibility fails for modules that declare stacks or targets none
+// of which is the one of the builder. Distributions are only compared when
+// both the module and the builder target declare some.
+func checkCompatibility(m module, stackID string, builder Target) error {
+	if len(m.layer.Stacks) > 0 && stackID != "" {
+		var ids []string
+		for _, stack := range m.layer.Stacks {
+			if stack.ID == stackID || stack.ID == "*" {
+				return nil
+			}
+			ids = append(ids, stack.ID)
+		}
+
+		return fmt.Errorf("%s@%s supports the stacks %q but not %s", m.info.ID, m.info.Version, ids, stackID)
+	}
+
+	if len(m.layer.Targets) > 0 {
+		var targets []string
+		for _, target := range m.layer.Targets {
+			if (target.OS == "" || target.OS == builder.OS) &&
+				(target.Arch == "" || target.Arch == builder.Arch) &&
+				(target.ArchVariant == "" || target.ArchVariant == builder.ArchVariant) &&
+				supportsDistros(target.Distros, builder.Distros) {
+				return nil
+			}
+			targets = append(targets, target.String())
+		}
+
+		return fmt.Errorf("%s@%s supports the targets %q but not %s", m.info.ID, m.info.Version, targets, builder.String())
+	}
+
+	return nil
+}
+
+// supportsDistros reports whether any of the distributions of a module is
+// one of the builder. An empty version matches any version.
+func supportsDistros(module, builder []TargetDistro) bool {
+	if len(module) == 0 || len(builder) == 0 {
+		return true
+	}
+
+	for _, m := range module {
+		for _, b := range builder {
+			if m.Name == b.Name && (m.Version == "" || m.Version == b.Version) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// checkExtensions loads the extensions of options for every platform and
+// fails for those that do not support the stack, so that pack builders are
+// checked as AssembleBuilder checks its own.
+func checkExtensions(options BuilderOptions) error {
+	platforms, err := options.platforms()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, platform := range platforms {
+		target, err := checkTargets(options.Targets, platform)
+		if err != nil {
+			return err
+		}
+
+		extensions, err := loadModules(extensionKind, options.Extensions, platform, types.OCILayer)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range extensions {
+			errs = append(errs, checkCompatibility(m, options.StackID, target))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkTargets returns the target of the builder for platform, which fails
+// when the builder declares targets none of which is platform.
+func checkTargets(targets []Target, platform v1.Platform) (Target, error) {
+	if len(targets) == 0 {
+		return Target{OS: platform.OS, Arch: platform.Architecture, ArchVariant: platform.Variant}, nil
+	}
+
+	var declared []string
+	for _, target := range targets {
+		if target.OS == platform.OS && target.Arch == platform.Architecture && target.ArchVariant == platform.Variant {
+			return target, nil
+		}
+		declared = append(declared, target.Platform())
+	}
+
+	return Target{}, fmt.Errorf("platform %s is not a target of the builder, expected one of %q", platform.String(), declared)
+}
+
+// resolveOrder pins the version of every order entry to the one module of
+// the builder with its id.
+func resolveOrder(kind string, groups []OrderGroup, modules []module) ([]OrderTable, error) {
+	versions := map[string][]string{}
+	for _, m := range modules {
+		versions[m.info.ID] = append(versions[m.info.ID], m.info.Version)
+	}
+
+	order := []OrderTable{}
+	var errs []error
+	for index, group := range groups {
+		var resolved OrderGroup
+		for _, entry := range group {
+			available := versions[entry.ID]
+			switch {
+			case len(available) == 0:
+				errs = append(errs, fmt.Errorf("%s group %d: %s is not part of the builder", kind, index, entry.ID))
+			case entry.Version == "" && len(available) > 1:
+				errs = append(errs, fmt.Errorf("%s group %d: %s is ambiguous, pick one of the versions %q", kind, index, entry.ID, available))
+			case entry.Version == "":
+				entry.Version = available[0]
+			case !contains(available, entry.Version):
+				errs = append(errs, fmt.Errorf("%s group %d: %s@%s is not part of the builder, found %q", kind, index, entry.ID, entry.Version, available))
+			}
+			resolved = append(resolved, entry)
+		}
+		order = append(order, OrderTable{Group: resolved})
+	}
+
+	return order, errors.Join(errs...)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func infos(modules []module) []ModuleInfo {
+	result := []ModuleInfo{}
+	for _, m := range modules {
+		result = append(result, m.info)
+	}
+	return result
+}
+
+func moduleLayers(modules []module) ModuleLayers {
+	layers := ModuleLayers{}
+	for _, m := range modules {
+		if layers[m.info.ID] == nil {
+			layers[m.info.ID] = map[string]ModuleLayer{}
+		}
+		layers[m.info.ID][m.info.Version] = m.layer
+	}
+	return layers
+}
+
+func creator() CreatorMetadata {
+	version := "(devel)"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		version = info.Main.Version
+	}
+
+	return CreatorMetadata{Name: "ubi-base-stack stack-tools", Version: version}
+}
+
+func encodeToml(value any) ([]byte, error) {
+	buffer := bytes.NewBuffer(nil)
+	err := toml.NewEncoder(buffer).Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// entry is a file, directory or symlink of a module or lifecycle, named
+// relative to its root.
+type entry struct {
+	header  tar.Header
+	content []byte
+}
+
+// readURI reads the directory or (gzipped) tarball at uri, a local path,
+// a file:// URI or an http(s) URL.
+func readURI(uri string) ([]entry, error) {
+	if strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://") {
+		response, err := http.Get(uri)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected response status %s", response.Status)
+		}
+
+		return readTarball(response.Body)
+	}
+
+	path := strings.TrimPrefix(uri, "file://")
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return readDir(path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return readTarball(file)
+}
+
+func readDir(dir string) ([]entry, error) {
+	var entries []entry
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(path)
+			if err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+
+		var content []byte
+		if info.Mode().IsRegular() {
+			content, err = os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+		}
+
+		entries = append(entries, entry{header: *header, content: content})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func readTarball(r io.Reader) ([]entry, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader io.Reader = buffered
+	if magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	var entries []entry
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir, tar.TypeReg, tar.TypeSymlink:
+		default:
+			continue
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "/"))
+		if name == "." || name == ".." || strings.HasPrefix(name, "../") {
+			continue
+		}
+		header.Name = name
+
+		content, err := io.ReadAll(archive)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry{header: *header, content: content})
+	}
+}
+
+// layerBuilder collects the entries of a layer, adding missing parent
+// directories owned by root.
+type layerBuilder struct {
+	entries []entry
+	seen    map[string]bool
+}
+
+func newLayerBuilder() *layerBuilder {
+	return &layerBuilder{seen: map[string]bool{}}
+}
+
+func (l *layerBuilder) dir(name string, uid, gid int) {
+	l.parents(name)
+	if l.seen[name] {
+		return
+	}
+	l.seen[name] = true
+
+	l.entries = append(l.entries, entry{header: tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     name + "/",
+		Mode:     0755,
+		Uid:      uid,
+		Gid:      gid,
+	}})
+}
+
+func (l *layerBuilder) file(name string, mode int64, content []byte) {
+	l.add(name, entry{
+		header:  tar.Header{Typeflag: tar.TypeReg, Mode: mode},
+		content: content,
+	})
+}
+
+// add copies e to name, owned by root.
+func (l *layerBuilder) add(name string, e entry) {
+	if e.header.Typeflag == tar.TypeDir {
+		l.dir(name, 0, 0)
+		return
+	}
+
+	l.parents(name)
+	l.seen[name] = true
+
+	l.entries = append(l.entries, entry{
+		header: tar.Header{
+			Typeflag: e.header.Typeflag,
+			Name:     name,
+			Linkname: e.header.Linkname,
+			Mode:     e.header.Mode,
+			Size:     int64(len(e.content)),
+		},
+		content: e.content,
+	})
+}
+
+func (l *layerBuilder) parents(name string) {
+	if parent := path.Dir(name); parent != "." && parent != "/" {
+		l.dir(parent, 0, 0)
+	}
+}
+
+func (l *layerBuilder) layer(mediaType types.MediaType) (v1.Layer, error) {
+	buffer := bytes.NewBuffer(nil)
+	writer := tar.NewWriter(buffer)
+	for _, e := range l.entries {
+		header := e.header
+		header.ModTime = normalizedTime
+
+		err := writer.WriteHeader(&header)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = writer.Write(e.content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	content := buffer.Bytes()
+	return tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}, tarball.WithMediaType(mediaType))
+}
@@ -0,0 +1,659 @@
+package utils_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/paketo-community/ubi-base-stack/internal/utils"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testAssemble(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dir     string
+		build   v1.Image
+		options utils.BuilderOptions
+	)
+
+	buildImage := func(arch string) v1.Image {
+		image, err := mutate.ConfigFile(mutate.MediaType(empty.Image, types.OCIManifestSchema1), &v1.ConfigFile{
+			OS:           "linux",
+			Architecture: arch,
+			Config: v1.Config{
+				Env:    []string{"CNB_USER_ID=1002", "CNB_GROUP_ID=1000"},
+				Labels: map[string]string{utils.StackIDLabel: "io.buildpacks.stacks.ubi8"},
+				User:   "1002:1000",
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		return image
+	}
+
+	buildIndex := func(archs ...string) v1.ImageIndex {
+		var addenda []mutate.IndexAddendum
+		for _, arch := range archs {
+			addenda = append(addenda, mutate.IndexAddendum{
+				Add:        buildImage(arch),
+				Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: arch}},
+			})
+		}
+
+		return mutate.AppendManifests(empty.Index, addenda...)
+	}
+
+	writeTarball := func(path string, files map[string]string) {
+		buffer := bytes.NewBuffer(nil)
+		gz := gzip.NewWriter(buffer)
+		writer := tar.NewWriter(gz)
+		var names []string
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			Expect(writer.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0755, Size: int64(len(files[name]))})).To(Succeed())
+			_, err := writer.Write([]byte(files[name]))
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(writer.Close()).To(Succeed())
+		Expect(gz.Close()).To(Succeed())
+		Expect(os.WriteFile(path, buffer.Bytes(), 0644)).To(Succeed())
+	}
+
+	files := func(image v1.Image) map[string]tar.Header {
+		headers := map[string]tar.Header{}
+		reader := tar.NewReader(mutate.Extract(image))
+		for {
+			header, err := reader.Next()
+			if errors.Is(err, io.EOF) {
+				return headers
+			}
+			Expect(err).NotTo(HaveOccurred())
+			headers[strings.TrimSuffix(header.Name, "/")] = *header
+		}
+	}
+
+	content := func(image v1.Image, path string) string {
+		reader := tar.NewReader(mutate.Extract(image))
+		for {
+			header, err := reader.Next()
+			Expect(err).NotTo(HaveOccurred())
+			if header.Name == path {
+				content, err := io.ReadAll(reader)
+				Expect(err).NotTo(HaveOccurred())
+				return string(content)
+			}
+		}
+	}
+
+	label := func(image v1.Image, key string, value any) {
+		config, err := image.ConfigFile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(json.Unmarshal([]byte(config.Config.Labels[key]), value)).To(Succeed())
+	}
+
+	it.Before(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "assemble")
+		Expect(err).NotTo(HaveOccurred())
+
+		build = buildImage("amd64")
+
+		writeTarball(filepath.Join(dir, "lifecycle.tgz"), map[string]string{
+			"lifecycle.toml": `[apis]
+[apis.buildpack]
+  deprecated = []
+  supported = ["0.7", "0.10"]
+[apis.platform]
+  deprecated = []
+  supported = ["0.7", "0.12"]
+
+[lifecycle]
+  version = "0.17.2"
+`,
+			"lifecycle/lifecycle": "lifecycle",
+			"lifecycle/launcher":  "launcher",
+		})
+
+		Expect(os.MkdirAll(filepath.Join(dir, "go-dist", "bin"), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "go-dist", "buildpack.toml"), []byte(`api = "0.7"
+
+[buildpack]
+  id = "paketo-buildpacks/go-dist"
+  version = "2.6.0"
+  homepage = "https://github.com/paketo-buildpacks/go-dist"
+
+[[stacks]]
+  id = "*"
+`), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "go-dist", "bin", "build"), []byte("build"), 0755)).To(Succeed())
+
+		writeTarball(filepath.Join(dir, "build-plan.tgz"), map[string]string{
+			"buildpack.toml": `api = "0.7"
+
+[buildpack]
+  id = "paketo-community/build-plan"
+  version = "0.1.0"
+
+[[targets]]
+  os = "linux"
+  arch = "amd64"
+`,
+			"bin/detect": "detect",
+		})
+
+		Expect(os.MkdirAll(filepath.Join(dir, "nodejs-extension", "bin"), os.ModePerm)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "nodejs-extension", "extension.toml"), []byte(`api = "0.9"
+
+[extension]
+  id = "paketo-community/ubi-nodejs-extension"
+  version = "1.0.0"
+
+[[targets]]
+  os = "linux"
+  arch = "amd64"
+
+  [[targets.distros]]
+    name = "rhel"
+    version = "8"
+`), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "nodejs-extension", "bin", "generate"), []byte("generate"), 0755)).To(Succeed())
+
+		options = utils.BuilderOptions{
+			StackID:     "io.buildpacks.stacks.ubi8",
+			Description: "ubi8 builder",
+			Buildpacks: []utils.BuilderModule{
+				{URI: filepath.Join(dir, "go-dist")},
+				{URI: filepath.Join(dir, "build-plan.tgz"), ID: "paketo-community/build-plan"},
+			},
+			Order: []utils.OrderGroup{
+				{{ID: "paketo-buildpacks/go-dist"}, {ID: "paketo-community/build-plan", Optional: true}},
+			},
+			Lifecycle: utils.BuilderLifecycle{URI: filepath.Join(dir, "lifecycle.tgz")},
+			RunImages: []utils.BuilderRunImage{
+				{Image: "registry.example.com/run:latest", Mirrors: []string{"docker.io/paketocommunity/run-ubi-base"}},
+				{Image: "registry.example.com/run-nodejs-20:latest"},
+			},
+			Targets:   []utils.Target{{OS: "linux", Arch: "amd64"}},
+			Labels:    map[string]string{"org.opencontainers.image.title": "test builder"},
+			Platforms: []string{"linux/amd64"},
+		}
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	context("AssembleBuilder", func() {
+		it("lays out the lifecycle, the buildpacks and their order on top of the build image", func() {
+			builder, err := utils.AssembleBuilder(build, options)
+			Expect(err).NotTo(HaveOccurred())
+
+			headers := files(builder)
+			Expect(headers).To(HaveKey("cnb/lifecycle/lifecycle"))
+			Expect(headers).To(HaveKey("cnb/lifecycle/launcher"))
+			Expect(headers).To(HaveKey("cnb/buildpacks/paketo-buildpacks_go-dist/2.6.0/buildpack.toml"))
+			Expect(headers).To(HaveKey("cnb/buildpacks/paketo-buildpacks_go-dist/2.6.0/bin/build"))
+			Expect(headers).To(HaveKey("cnb/buildpacks/paketo-community_build-plan/0.1.0/bin/detect"))
+			Expect(headers).To(HaveKey("platform/env"))
+			Expect(headers).NotTo(HaveKey("cnb/extensions"))
+			Expect(headers["workspace"].Uid).To(Equal(1002))
+			Expect(headers["layers"].Gid).To(Equal(1000))
+			Expect(headers["cnb/buildpacks/paketo-buildpacks_go-dist/2.6.0/bin/build"].Uid).To(Equal(0))
+
+			Expect(content(builder, "cnb/order.toml")).To(Equal(`[[order]]
+
+  [[order.group]]
+    id = "paketo-buildpacks/go-dist"
+    version = "2.6.0"
+
+  [[order.group]]
+    id = "paketo-community/build-plan"
+    version = "0.1.0"
+    optional = true
+`))
+			Expect(content(builder, "cnb/run.toml")).To(Equal(`[[images]]
+  image = "registry.example.com/run:latest"
+  mirrors = ["docker.io/paketocommunity/run-ubi-base"]
+
+[[images]]
+  image = "registry.example.com/run-nodejs-20:latest"
+`))
+			Expect(content(builder, "cnb/stack.toml")).To(Equal(`[run-image]
+  image = "registry.example.com/run:latest"
+  mirrors = ["docker.io/paketocommunity/run-ubi-base"]
+`))
+
+			config, err := builder.ConfigFile()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Config.WorkingDir).To(Equal("/layers"))
+			Expect(config.Config.User).To(Equal("1002:1000"))
+			Expect(config.Config.Labels).To(HaveKeyWithValue(utils.StackIDLabel, "io.buildpacks.stacks.ubi8"))
+			Expect(config.Config.Labels).To(HaveKeyWithValue("org.opencontainers.image.title", "test builder"))
+			Expect(config.Config.Labels).NotTo(HaveKey(utils.ExtensionLayersLabel))
+
+			var metadata utils.BuilderMetadata
+			label(builder, utils.BuilderMetadataLabel, &metadata)
+			Expect(metadata.Description).To(Equal("ubi8 builder"))
+			Expect(metadata.Stack.RunImage).To(Equal(utils.RunImageMetadata{
+				Image:   "registry.example.com/run:latest",
+				Mirrors: []string{"docker.io/paketocommunity/run-ubi-base"},
+			}))
+			Expect(metadata.Images).To(HaveLen(2))
+			Expect(metadata.Images[1].Image).To(Equal("registry.example.com/run-nodejs-20:latest"))
+			Expect(metadata.Buildpacks).To(Equal([]utils.ModuleInfo{
+				{ID: "paketo-buildpacks/go-dist", Version: "2.6.0", Homepage: "https://github.com/paketo-buildpacks/go-dist"},
+				{ID: "paketo-community/build-plan", Version: "0.1.0"},
+			}))
+			Expect(metadata.Lifecycle.Version).To(Equal("0.17.2"))
+			Expect(metadata.Lifecycle.API).To(Equal(utils.LifecycleAPI{BuildpackVersion: "0.7", PlatformVersion: "0.7"}))
+			Expect(metadata.Lifecycle.APIs.Platform.Supported).To(Equal([]string{"0.7", "0.12"}))
+
+			var layers utils.ModuleLayers
+			label(builder, utils.BuildpackLayersLabel, &layers)
+			Expect(layers).To(HaveLen(2))
+			Expect(layers["paketo-buildpacks/go-dist"]["2.6.0"].API).To(Equal("0.7"))
+			Expect(layers["paketo-buildpacks/go-dist"]["2.6.0"].Stacks).To(Equal([]utils.ModuleStack{{ID: "*"}}))
+			Expect(layers["paketo-community/build-plan"]["0.1.0"].Targets).To(Equal([]utils.Target{{OS: "linux", Arch: "amd64"}}))
+
+			diffID, err := v1.NewHash(layers["paketo-buildpacks/go-dist"]["2.6.0"].LayerDiffID)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = builder.LayerByDiffID(diffID)
+			Expect(err).NotTo(HaveOccurred())
+
+			var order []utils.OrderTable
+			label(builder, utils.BuildpackOrderLabel, &order)
+			Expect(order).To(Equal([]utils.OrderTable{{Group: utils.OrderGroup{
+				{ID: "paketo-buildpacks/go-dist", Version: "2.6.0"},
+				{ID: "paketo-community/build-plan", Version: "0.1.0", Optional: true},
+			}}}))
+		})
+
+		it("produces the same builder for the same inputs", func() {
+			first, err := utils.AssembleBuilder(build, options)
+			Expect(err).NotTo(HaveOccurred())
+
+			second, err := utils.AssembleBuilder(build, options)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(first.Digest()).To(Equal(must(second.Digest())))
+		})
+
+		it("reuses the layers of buildpackage images", func() {
+			server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+			defer server.Close()
+
+			source, err := utils.AssembleBuilder(build, options)
+			Expect(err).NotTo(HaveOccurred())
+
+			config, err := source.ConfigFile()
+			Expect(err).NotTo(HaveOccurred())
+
+			labels := config.Config.Labels
+			labels[utils.BuildpackageLabel] = `{"id":"paketo-buildpacks/go-dist","version":"2.6.0"}`
+			buildpackage, err := mutate.Config(source, config.Config)
+			Expect(err).NotTo(HaveOccurred())
+
+			ref := strings.TrimPrefix(server.URL, "http://") + "/go-dist:2.6.0"
+			Expect(utils.PublishImage(ref, buildpackage)).To(Succeed())
+
+			options.Buildpacks = []utils.BuilderModule{{URI: "docker://" + ref, ID: "paketo-buildpacks/go-dist"}}
+			options.Order = []utils.OrderGroup{{{ID: "paketo-buildpacks/go-dist"}, {ID: "paketo-community/build-plan"}}}
+
+			builder, err := utils.AssembleBuilder(build, options)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(files(builder)).To(HaveKey("cnb/buildpacks/paketo-community_build-plan/0.1.0/bin/detect"))
+
+			var layers utils.ModuleLayers
+			label(builder, utils.BuildpackLayersLabel, &layers)
+			Expect(layers).To(HaveKey("paketo-buildpacks/go-dist"))
+			Expect(layers).To(HaveKey("paketo-community/build-plan"))
+		})
+
+		context("with extensions", func() {
+			it.Before(func() {
+				options.Extensions = []utils.BuilderModule{{URI: filepath.Join(dir, "nodejs-extension")}}
+				options.OrderExtensions = []utils.OrderGroup{{{ID: "paketo-community/ubi-nodejs-extension", Optional: true}}}
+				options.Targets[0].Distros = []utils.TargetDistro{{Name: "rhel", Version: "8"}}
+			})
+
+			it("lays out the extensions and their order", func() {
+				builder, err := utils.AssembleBuilder(build, options)
+				Expect(err).NotTo(HaveOccurred())
+
+				headers := files(builder)
+				Expect(headers).To(HaveKey("cnb/extensions"))
+				Expect(headers).To(HaveKey("cnb/extensions/paketo-community_ubi-nodejs-extension/1.0.0/bin/generate"))
+
+				Expect(content(builder, "cnb/order.toml")).To(ContainSubstring(`[[order-extensions]]
+
+  [[order-extensions.group]]
+    id = "paketo-community/ubi-nodejs-extension"
+    version = "1.0.0"
+    optional = true`))
+
+				var order []utils.OrderTable
+				label(builder, utils.ExtensionOrderLabel, &order)
+				Expect(order).To(Equal([]utils.OrderTable{
+					{Group: utils.OrderGroup{{ID: "paketo-community/ubi-nodejs-extension", Version: "1.0.0", Optional: true}}},
+				}))
+
+				var layers utils.ModuleLayers
+				label(builder, utils.ExtensionLayersLabel, &layers)
+				Expect(layers["paketo-community/ubi-nodejs-extension"]["1.0.0"].Targets).To(Equal([]utils.Target{
+					{OS: "linux", Arch: "amd64", Distros: []utils.TargetDistro{{Name: "rhel", Version: "8"}}},
+				}))
+
+				var metadata utils.BuilderMetadata
+				label(builder, utils.BuilderMetadataLabel, &metadata)
+				Expect(metadata.Extensions).To(Equal([]utils.ModuleInfo{{ID: "paketo-community/ubi-nodejs-extension", Version: "1.0.0"}}))
+			})
+
+			it("accepts extensions of any distribution on builders without one", func() {
+				options.Targets[0].Distros = nil
+
+				_, err := utils.AssembleBuilder(build, options)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			context("failure cases", func() {
+				it("rejects extensions that do not support the distribution of the stack", func() {
+					options.Targets[0].Distros = []utils.TargetDistro{{Name: "rhel", Version: "9"}}
+
+					_, err := utils.AssembleBuilder(build, options)
+					Expect(err).To(MatchError(`paketo-community/ubi-nodejs-extension@1.0.0 supports the targets ["linux/amd64 (rhel 8)"] but not linux/amd64 (rhel 9)`))
+				})
+			})
+		})
+
+		context("failure cases", func() {
+			it("requires a pinned lifecycle", func() {
+				options.Lifecycle = utils.BuilderLifecycle{}
+
+				_, err := utils.AssembleBuilder(build, options)
+				Expect(err).To(MatchError("builder options: the go assembler requires a lifecycle version or uri"))
+			})
+
+			it("rejects build images without a CNB user", func() {
+				var err error
+				build, err = mutate.Config(build, v1.Config{Labels: map[string]string{utils.StackIDLabel: "io.buildpacks.stacks.ubi8"}})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = utils.AssembleBuilder(build, options)
+				Expect(err).To(MatchError("the build image does not set CNB_USER_ID"))
+			})
+
+			it("rejects build images of another stack", func() {
+				options.StackID = "io.buildpacks.stacks.ubi9"
+
+				_, err := utils.AssembleBuilder(build, options)
+				Expect(err).To(MatchError(`the build image has stack id "io.buildpacks.stacks.ubi8", expected "io.buildpacks.stacks.ubi9"`))
+			})
+
+			it("reports modules that are not what the options expect", func() {
+				options.Buildpacks[0].Version = "2.7.0"
+				options.Buildpacks[1].URI = filepath.Join(dir, "missing.tgz")
+
+				_, err := utils.AssembleBuilder(build, options)
+				Expect(err).To(MatchError(SatisfyAll(
+					ContainSubstring("found paketo-buildpacks/go-dist@2.6.0, expected version 2.7.0"),
+					ContainSubstring("missing.tgz: stat"),
+				)))
+			})
+
+			it("rejects platforms that are not targets of the builder", func() {
+				build = buildImage("arm64")
+
+				_, err := utils.AssembleBuilder(build, options)
+				Expect(err).To(MatchError(`platform linux/arm64 is not a target of the builder, expected one of ["linux/amd64"]`))
+			})
+
+			it("reports incompatible modules and unresolvable order entries", func() {
+				build = buildImage("arm64")
+				options.Targets = nil
+				options.Order = append(options.Order, utils.OrderGroup{{ID: "paketo-buildpacks/nodejs"}, {ID: "paketo-buildpacks/go-dist", Version: "1.0.0"}})
+
+				_, err := utils.AssembleBuilder(build, options)
+				Expect(err).To(MatchError(SatisfyAll(
+					ContainSubstring(`paketo-community/build-plan@0.1.0 supports the targets ["linux/amd64"] but not linux/arm64`),
+					ContainSubstring("order group 1: paketo-buildpacks/nodejs is not part of the builder"),
+					ContainSubstring(`order group 1: paketo-buildpacks/go-dist@1.0.0 is not part of the builder, found ["2.6.0"]`),
+				)))
+			})
+		})
+	})
+
+	context("AssembleBuilderIndex", func() {
+		it.Before(func() {
+			lifecycle, err := os.ReadFile(filepath.Join(dir, "lifecycle.tgz"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(dir, "lifecycle-x86-64.tgz"), lifecycle, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "lifecycle-arm64.tgz"), lifecycle, 0644)).To(Succeed())
+
+			options.Lifecycle.URI = filepath.Join(dir, "lifecycle-{arch}.tgz")
+			options.Buildpacks = options.Buildpacks[:1]
+			options.Order = []utils.OrderGroup{{{ID: "paketo-buildpacks/go-dist"}}}
+			options.Targets = []utils.Target{{OS: "linux", Arch: "amd64"}, {OS: "linux", Arch: "arm64"}}
+			options.Platforms = []string{"linux/amd64", "linux/arm64"}
+		})
+
+		it("creates a builder per platform on top of the build image of the platform", func() {
+			build := buildIndex("amd64", "arm64", "s390x")
+
+			index, err := utils.AssembleBuilderIndex(build, options)
+			Expect(err).NotTo(HaveOccurred())
+
+			manifest, err := index.IndexManifest()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.MediaType).To(Equal(types.OCIImageIndex))
+			Expect(manifest.Manifests).To(HaveLen(2))
+
+			for i, arch := range []string{"amd64", "arm64"} {
+				descriptor := manifest.Manifests[i]
+				Expect(descriptor.Platform).To(Equal(&v1.Platform{OS: "linux", Architecture: arch}))
+
+				builder, err := index.Image(descriptor.Digest)
+				Expect(err).NotTo(HaveOccurred())
+
+				base, err := build.Image(must(build.IndexManifest()).Manifests[i].Digest)
+				Expect(err).NotTo(HaveOccurred())
+
+				builderManifest, err := builder.Manifest()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(builderManifest.Annotations).To(HaveKeyWithValue(utils.BaseDigestAnnotation, must(base.Digest()).String()))
+
+				config, err := builder.ConfigFile()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Architecture).To(Equal(arch))
+				Expect(files(builder)).To(HaveKey("cnb/lifecycle/lifecycle"))
+			}
+
+			Expect(utils.VerifyBuilder(index, build, map[string]v1.ImageIndex{"run": buildIndex("amd64", "arm64")})).To(Succeed())
+		})
+
+		context("failure cases", func() {
+			it("requires a build image for every platform", func() {
+				_, err := utils.AssembleBuilderIndex(buildIndex("amd64"), options)
+				Expect(err).To(MatchError(`build image: no image for platform linux/arm64, found ["linux/amd64"]`))
+			})
+
+			it("reports the platform of the builder that cannot be assembled", func() {
+				options.Lifecycle.URI = filepath.Join(dir, "lifecycle.tgz")
+				options.Platforms = []string{"linux/arm64"}
+				options.Targets = []utils.Target{{OS: "linux", Arch: "amd64"}}
+
+				_, err := utils.AssembleBuilderIndex(buildIndex("arm64"), options)
+				Expect(err).To(MatchError(`linux/arm64: platform linux/arm64 is not a target of the builder, expected one of ["linux/amd64"]`))
+			})
+		})
+	})
+
+	context("VerifyBuilder", func() {
+		var builder v1.ImageIndex
+
+		it.Before(func() {
+			var err error
+			builder, err = utils.SinglePlatformIndex(must(utils.AssembleBuilder(build, options)))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it("accepts a builder built on the build image", func() {
+			build, err := utils.SinglePlatformIndex(build)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(utils.VerifyBuilder(builder, build, nil)).To(Succeed())
+		})
+
+		context("failure cases", func() {
+			it("rejects builders built on another build image", func() {
+				other, err := mutate.Config(build, v1.Config{Labels: map[string]string{"other": "true"}})
+				Expect(err).NotTo(HaveOccurred())
+
+				index, err := utils.SinglePlatformIndex(other)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = utils.VerifyBuilder(builder, index, nil)
+				Expect(err).To(MatchError(fmt.Sprintf("builder for linux/amd64: base image is %s but the build image is %s", must(build.Digest()), must(other.Digest()))))
+			})
+
+			it("rejects builders missing the layers of the build image", func() {
+				base, err := mutate.AppendLayers(build, must(random.Layer(16, types.OCILayer)))
+				Expect(err).NotTo(HaveOccurred())
+
+				// like builders created by pack, without a base digest annotation
+				other, err := mutate.AppendLayers(build, must(random.Layer(16, types.OCILayer)))
+				Expect(err).NotTo(HaveOccurred())
+
+				builder, err := utils.SinglePlatformIndex(other)
+				Expect(err).NotTo(HaveOccurred())
+
+				err = utils.VerifyBuilder(builder, must(utils.SinglePlatformIndex(base)), nil)
+				Expect(err).To(MatchError(fmt.Sprintf("builder for linux/amd64: image does not contain the layers of the build image %s", must(base.Digest()))))
+			})
+
+			it("rejects run images without the platform of the builder", func() {
+				err := utils.VerifyBuilder(builder, buildIndex("amd64"), map[string]v1.ImageIndex{"run": buildIndex("arm64")})
+				Expect(err).To(MatchError(SatisfyAll(
+					ContainSubstring("builder for linux/amd64: "),
+					ContainSubstring(`run image run: no image for platform linux/amd64, found ["linux/arm64"]`),
+				)))
+			})
+		})
+	})
+
+	context("archives", func() {
+		it("round-trips images through OCI archives", func() {
+			builder, err := utils.AssembleBuilder(build, options)
+			Expect(err).NotTo(HaveOccurred())
+
+			index, err := utils.SinglePlatformIndex(builder)
+			Expect(err).NotTo(HaveOccurred())
+
+			path := filepath.Join(dir, "builder.oci")
+			Expect(utils.WriteArchive(path, index)).To(Succeed())
+
+			archive, err := utils.OpenArchive(path)
+			Expect(err).NotTo(HaveOccurred())
+			defer archive.Close()
+
+			image, err := archive.Image(v1.Platform{OS: "linux", Architecture: "amd64"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(image.Digest()).To(Equal(must(builder.Digest())))
+
+			_, err = archive.Image(v1.Platform{OS: "linux", Architecture: "s390x"})
+			Expect(err).To(MatchError(`no image for platform linux/s390x, found ["linux/amd64"]`))
+		})
+	})
+
+	context("GenerateBuilder", func() {
+		it("publishes the images and the builder without pack or jam", func() {
+			server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+			defer server.Close()
+
+			index, err := utils.SinglePlatformIndex(build)
+			Expect(err).NotTo(HaveOccurred())
+
+			options.BuildImage = filepath.Join(dir, "build.oci")
+			Expect(utils.WriteArchive(options.BuildImage, index)).To(Succeed())
+			Expect(utils.WriteArchive(filepath.Join(dir, "run.oci"), index)).To(Succeed())
+			options.RunImages = []utils.BuilderRunImage{
+				{Archive: filepath.Join(dir, "run.oci")},
+				{Image: "docker.io/paketocommunity/run-nodejs-20-ubi8-base:latest"},
+			}
+
+			options.RegistryURL = strings.TrimPrefix(server.URL, "http://")
+			options.Assembler = utils.AssemblerGo
+
+			generated, err := utils.GenerateBuilder(options)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(generated.RunImages).To(HaveLen(1))
+
+			reference, err := name.ParseReference(generated.Builder)
+			Expect(err).NotTo(HaveOccurred())
+
+			builder, err := remote.Image(reference)
+			Expect(err).NotTo(HaveOccurred())
+
+			var metadata utils.BuilderMetadata
+			label(builder, utils.BuilderMetadataLabel, &metadata)
+			Expect(metadata.Images).To(Equal([]utils.RunImageMetadata{
+				{Image: generated.RunImages[0] + ":latest"},
+				{Image: "docker.io/paketocommunity/run-nodejs-20-ubi8-base:latest"},
+			}))
+
+			reference, err = name.ParseReference(generated.RunImages[0])
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = remote.Index(reference)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		context("failure cases", func() {
+			it("checks the extensions against the stack before pack runs", func() {
+				options.BuildImage = filepath.Join(dir, "build.oci")
+				options.RegistryURL = "127.0.0.1:5000"
+				options.Lifecycle = utils.BuilderLifecycle{}
+				options.Extensions = []utils.BuilderModule{{URI: filepath.Join(dir, "nodejs-extension")}}
+				options.Targets = []utils.Target{{OS: "linux", Arch: "amd64", Distros: []utils.TargetDistro{{Name: "rhel", Version: "9"}}}}
+
+				_, err := utils.GenerateBuilder(options)
+				Expect(err).To(MatchError(`paketo-community/ubi-nodejs-extension@1.0.0 supports the targets ["linux/amd64 (rhel 8)"] but not linux/amd64 (rhel 9)`))
+			})
+		})
+	})
+}
+
+func must[T any](value T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
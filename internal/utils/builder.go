@@ -0,0 +1,307 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// BuilderOptions describes the builder GenerateBuilder creates.
+type BuilderOptions struct {
+	// StackID is the stack id of the build and run images. The go assembler
+	// requires it, pack reads the stack id from the images.
+	StackID string
+	// BuildImage is the path of the OCI archive of the build image.
+	BuildImage string
+	// RunImages are the run images of the builder. The first one is used
+	// unless the target or an extension picks another one.
+	RunImages []BuilderRunImage
+	// RegistryURL is the registry the images and the builder are pushed to.
+	RegistryURL string
+	// Targets are the platforms and distributions the builder supports.
+	Targets []Target
+
+	Description     string
+	Buildpacks      []BuilderModule
+	Extensions      []BuilderModule
+	Order           []OrderGroup
+	OrderExtensions []OrderGroup
+	Lifecycle       BuilderLifecycle
+	// Labels are added to the builder image.
+	Labels map[string]string
+
+	// Assembler selects how GenerateBuilder creates the builder, pack when
+	// empty.
+	Assembler string
+	// Platforms are the os/arch[/variant] the builder is created for, one
+	// builder of the published index each. Only the platform of the host
+	// when empty.
+	Platforms []string
+}
+
+// LifecycleArchPlaceholder is replaced with the lifecycle name of the
+// architecture in the lifecycle URI of multi-platform builders, e.g.
+// lifecycle-v0.17.2+linux.{arch}.tgz.
+const LifecycleArchPlaceholder = "{arch}"
+
+// Builder assemblers.
+const (
+	// AssemblerPack runs pack builder create.
+	AssemblerPack = "pack"
+	// AssemblerGo assembles the builder in process, see AssembleBuilder.
+	AssemblerGo = "go"
+)
+
+// BuilderRunImage is a run image of a builder, either an OCI archive that
+// GenerateBuilder pushes or the reference of a published image.
+type BuilderRunImage struct {
+	Archive string
+	Image   string
+	Mirrors []string
+}
+
+// BuilderModule is a buildpack or extension of a builder, located by URI.
+type BuilderModule struct {
+	URI     string `toml:"uri"`
+	ID      string `toml:"id,omitempty"`
+	Version string `toml:"version,omitempty"`
+}
+
+// OrderGroup is one group of the detection order of a builder.
+type OrderGroup []OrderEntry
+
+// OrderEntry references a buildpack or extension of the builder.
+type OrderEntry struct {
+	ID       string `toml:"id" json:"id"`
+	Version  string `toml:"version,omitempty" json:"version,omitempty"`
+	Optional bool   `toml:"optional,omitempty" json:"optional,omitempty"`
+}
+
+// BuilderLifecycle pins the lifecycle of a builder, either by version or by
+// the URI of a lifecycle archive. pack picks its own default when both are
+// empty. The go assembler expands LifecycleArchPlaceholder in the URI.
+type BuilderLifecycle struct {
+	Version string `toml:"version,omitempty"`
+	URI     string `toml:"uri,omitempty"`
+}
+
+// Validate checks that the options describe a builder pack can create.
+func (o BuilderOptions) Validate() error {
+	var errs []error
+
+	required := []struct {
+		name  string
+		value string
+	}{
+		{name: "BuildImage", value: o.BuildImage},
+		{name: "RegistryURL", value: o.RegistryURL},
+	}
+	for _, field := range required {
+		if field.value == "" {
+			errs = append(errs, fmt.Errorf("builder options: %s must not be empty", field.name))
+		}
+	}
+
+	if len(o.RunImages) == 0 {
+		errs = append(errs, errors.New("builder options: RunImages must not be empty"))
+	}
+
+	for index, runImage := range o.RunImages {
+		if (runImage.Archive == "") == (runImage.Image == "") {
+			errs = append(errs, fmt.Errorf("builder options: run image %d: exactly one of archive or image must be set", index))
+		}
+	}
+
+	for index, target := range o.Targets {
+		if target.OS == "" || target.Arch == "" {
+			errs = append(errs, fmt.Errorf("builder options: target %d: os and arch must not be empty", index))
+		}
+	}
+
+	if o.Lifecycle.Version != "" && o.Lifecycle.URI != "" {
+		errs = append(errs, errors.New("builder options: lifecycle version and uri are mutually exclusive"))
+	}
+
+	_, err := o.platforms()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	placeholder := strings.Contains(o.Lifecycle.URI, LifecycleArchPlaceholder)
+
+	switch o.Assembler {
+	case "", AssemblerPack:
+		if placeholder {
+			errs = append(errs, fmt.Errorf("builder options: pack does not expand %s in the lifecycle uri", LifecycleArchPlaceholder))
+		}
+	case AssemblerGo:
+		if o.StackID == "" {
+			errs = append(errs, errors.New("builder options: StackID must not be empty"))
+		}
+
+		if o.Lifecycle == (BuilderLifecycle{}) {
+			errs = append(errs, errors.New("builder options: the go assembler requires a lifecycle version or uri"))
+		}
+
+		if len(o.Platforms) > 1 && o.Lifecycle.URI != "" && !placeholder {
+			errs = append(errs, fmt.Errorf("builder options: the lifecycle uri must contain %s to create builders for several platforms", LifecycleArchPlaceholder))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("builder options: assembler %q must be one of %q", o.Assembler, []string{AssemblerPack, AssemblerGo}))
+	}
+
+	modules := []struct {
+		kind    string
+		modules []BuilderModule
+	}{
+		{kind: "buildpack", modules: o.Buildpacks},
+		{kind: "extension", modules: o.Extensions},
+	}
+	for _, m := range modules {
+		for index, module := range m.modules {
+			if module.URI == "" {
+				errs = append(errs, fmt.Errorf("builder options: %s %d: uri must not be empty", m.kind, index))
+			}
+		}
+	}
+
+	if len(o.OrderExtensions) > 0 && len(o.Extensions) == 0 {
+		errs = append(errs, errors.New("builder options: order-extensions require extensions"))
+	}
+
+	orders := []struct {
+		kind   string
+		groups []OrderGroup
+	}{
+		{kind: "order", groups: o.Order},
+		{kind: "order-extensions", groups: o.OrderExtensions},
+	}
+	for _, order := range orders {
+		for index, group := range order.groups {
+			if len(group) == 0 {
+				errs = append(errs, fmt.Errorf("builder options: %s group %d is empty", order.kind, index))
+			}
+
+			for _, entry := range group {
+				if entry.ID == "" {
+					errs = append(errs, fmt.Errorf("builder options: %s group %d: id must not be empty", order.kind, index))
+				}
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// BuilderToml renders the builder.toml of a builder made of the pushed build
+// image and the run images of the options, which must all be references.
+func (o BuilderOptions) BuilderToml(buildImageUrl string) ([]byte, error) {
+	groups := func(order []OrderGroup) []OrderTable {
+		var result []OrderTable
+		for _, g := range order {
+			result = append(result, OrderTable{Group: g})
+		}
+		return result
+	}
+
+	var lifecycle *BuilderLifecycle
+	if o.Lifecycle != (BuilderLifecycle{}) {
+		lifecycle = &o.Lifecycle
+	}
+
+	config := struct {
+		Description     string            `toml:"description,omitempty"`
+		Buildpacks      []BuilderModule   `toml:"buildpacks,omitempty"`
+		Extensions      []BuilderModule   `toml:"extensions,omitempty"`
+		Order           []OrderTable      `toml:"order,omitempty"`
+		OrderExtensions []OrderTable      `toml:"order-extensions,omitempty"`
+		Lifecycle       *BuilderLifecycle `toml:"lifecycle,omitempty"`
+		Build           struct {
+			Image string `toml:"image"`
+		} `toml:"build"`
+		Run struct {
+			Images []RunImageMetadata `toml:"images"`
+		} `toml:"run"`
+		Targets []Target `toml:"targets,omitempty"`
+	}{
+		Description:     o.Description,
+		Buildpacks:      o.Buildpacks,
+		Extensions:      o.Extensions,
+		Order:           groups(o.Order),
+		OrderExtensions: groups(o.OrderExtensions),
+		Lifecycle:       lifecycle,
+		Targets:         o.Targets,
+	}
+
+	if len(config.Targets) == 0 && len(o.Platforms) > 0 {
+		platforms, err := o.platforms()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, platform := range platforms {
+			config.Targets = append(config.Targets, Target{OS: platform.OS, Arch: platform.Architecture, ArchVariant: platform.Variant})
+		}
+	}
+
+	config.Build.Image = fmt.Sprintf("%s:latest", buildImageUrl)
+
+	var err error
+	config.Run.Images, err = o.runImages()
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	err = toml.NewEncoder(buffer).Encode(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// platforms returns the parsed platforms of the options, the platform of the
+// host when there are none.
+func (o BuilderOptions) platforms() ([]v1.Platform, error) {
+	if len(o.Platforms) == 0 {
+		platform, err := ParsePlatform("")
+		return []v1.Platform{platform}, err
+	}
+
+	var (
+		platforms []v1.Platform
+		errs      []error
+	)
+	for _, value := range o.Platforms {
+		platform, err := ParsePlatform(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("builder options: platforms: %w", err))
+			continue
+		}
+		platforms = append(platforms, platform)
+	}
+
+	return platforms, errors.Join(errs...)
+}
+
+// runImages returns the references of the run images.
+func (o BuilderOptions) runImages() ([]RunImageMetadata, error) {
+	if len(o.RunImages) == 0 {
+		return nil, errors.New("builder options: RunImages must not be empty")
+	}
+
+	var images []RunImageMetadata
+	for index, runImage := range o.RunImages {
+		if runImage.Image == "" {
+			return nil, fmt.Errorf("builder options: run image %d has not been pushed", index)
+		}
+		images = append(images, RunImageMetadata{Image: runImage.Image, Mirrors: runImage.Mirrors})
+	}
+
+	return images, nil
+}
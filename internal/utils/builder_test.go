@@ -0,0 +1,222 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/paketo-community/ubi-base-stack/internal/utils"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testBuilder(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		options utils.BuilderOptions
+	)
+
+	it.Before(func() {
+		options = utils.BuilderOptions{
+			StackID:     "io.buildpacks.stacks.ubi8",
+			BuildImage:  "builds/build/build.oci",
+			RunImages:   []utils.BuilderRunImage{{Archive: "builds/build/run.oci"}},
+			RegistryURL: "127.0.0.1:5000",
+		}
+	})
+
+	context("BuilderToml", func() {
+		it.Before(func() {
+			options.RunImages = []utils.BuilderRunImage{{Image: "127.0.0.1:5000/run-image:latest"}}
+		})
+
+		it("renders a bare builder", func() {
+			content, err := options.BuilderToml("127.0.0.1:5000/build-image")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal(`[build]
+  image = "127.0.0.1:5000/build-image:latest"
+
+[run]
+
+  [[run.images]]
+    image = "127.0.0.1:5000/run-image:latest"
+`))
+		})
+
+		it("renders buildpacks, extensions, orders, the lifecycle and mirrors", func() {
+			options.Description = "ubi8 builder"
+			options.Buildpacks = []utils.BuilderModule{
+				{URI: "docker://gcr.io/paketo-buildpacks/nodejs:7.2.1"},
+				{URI: "./go-dist.tgz", ID: "paketo-buildpacks/go-dist", Version: "2.6.0"},
+			}
+			options.Extensions = []utils.BuilderModule{
+				{URI: "docker://docker.io/paketocommunity/ubi-nodejs-extension:1.0.0"},
+			}
+			options.Order = []utils.OrderGroup{
+				{{ID: "paketo-buildpacks/nodejs"}},
+				{{ID: "paketo-buildpacks/go-dist", Version: "2.6.0"}, {ID: "paketo-community/build-plan", Optional: true}},
+			}
+			options.OrderExtensions = []utils.OrderGroup{
+				{{ID: "paketo-community/ubi-nodejs-extension", Optional: true}},
+			}
+			options.Lifecycle = utils.BuilderLifecycle{Version: "0.20.1"}
+			options.RunImages = []utils.BuilderRunImage{
+				{Image: "127.0.0.1:5000/run-image:latest", Mirrors: []string{"docker.io/paketocommunity/run-ubi-base"}},
+				{Image: "127.0.0.1:5000/run-nodejs-20-image:latest"},
+			}
+			options.Targets = []utils.Target{{
+				OS:      "linux",
+				Arch:    "amd64",
+				Distros: []utils.TargetDistro{{Name: "rhel", Version: "8"}},
+			}}
+
+			content, err := options.BuilderToml("127.0.0.1:5000/build-image")
+			Expect(err).NotTo(HaveOccurred())
+
+			var builder map[string]any
+			_, err = toml.Decode(string(content), &builder)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(builder).To(Equal(map[string]any{
+				"description": "ubi8 builder",
+				"buildpacks": []map[string]any{
+					{"uri": "docker://gcr.io/paketo-buildpacks/nodejs:7.2.1"},
+					{"uri": "./go-dist.tgz", "id": "paketo-buildpacks/go-dist", "version": "2.6.0"},
+				},
+				"extensions": []map[string]any{
+					{"uri": "docker://docker.io/paketocommunity/ubi-nodejs-extension:1.0.0"},
+				},
+				"order": []map[string]any{
+					{"group": []map[string]any{{"id": "paketo-buildpacks/nodejs"}}},
+					{"group": []map[string]any{
+						{"id": "paketo-buildpacks/go-dist", "version": "2.6.0"},
+						{"id": "paketo-community/build-plan", "optional": true},
+					}},
+				},
+				"order-extensions": []map[string]any{
+					{"group": []map[string]any{{"id": "paketo-community/ubi-nodejs-extension", "optional": true}}},
+				},
+				"lifecycle": map[string]any{"version": "0.20.1"},
+				"build":     map[string]any{"image": "127.0.0.1:5000/build-image:latest"},
+				"run": map[string]any{
+					"images": []map[string]any{
+						{"image": "127.0.0.1:5000/run-image:latest", "mirrors": []any{"docker.io/paketocommunity/run-ubi-base"}},
+						{"image": "127.0.0.1:5000/run-nodejs-20-image:latest"},
+					},
+				},
+				"targets": []map[string]any{
+					{"os": "linux", "arch": "amd64", "distros": []map[string]any{{"name": "rhel", "version": "8"}}},
+				},
+			}))
+		})
+
+		it("derives the targets from the platforms", func() {
+			options.Platforms = []string{"linux/amd64", "linux/arm64/v8"}
+
+			content, err := options.BuilderToml("127.0.0.1:5000/build-image")
+			Expect(err).NotTo(HaveOccurred())
+
+			var builder struct {
+				Targets []utils.Target `toml:"targets"`
+			}
+			_, err = toml.Decode(string(content), &builder)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(builder.Targets).To(Equal([]utils.Target{
+				{OS: "linux", Arch: "amd64"},
+				{OS: "linux", Arch: "arm64", ArchVariant: "v8"},
+			}))
+		})
+
+		context("failure cases", func() {
+			it("requires pushed run images", func() {
+				options.RunImages = append(options.RunImages, utils.BuilderRunImage{Archive: "builds/build-nodejs-20/run.oci"})
+
+				_, err := options.BuilderToml("127.0.0.1:5000/build-image")
+				Expect(err).To(MatchError("builder options: run image 1 has not been pushed"))
+			})
+		})
+	})
+
+	context("Validate", func() {
+		it("accepts complete options", func() {
+			Expect(options.Validate()).To(Succeed())
+		})
+
+		context("failure cases", func() {
+			it("reports every problem", func() {
+				options = utils.BuilderOptions{
+					Lifecycle:  utils.BuilderLifecycle{Version: "0.20.1", URI: "https://example.com/lifecycle.tgz"},
+					Buildpacks: []utils.BuilderModule{{ID: "paketo-buildpacks/nodejs"}},
+					Order:      []utils.OrderGroup{{}, {{Version: "1.0.0"}}},
+					Targets:    []utils.Target{{OS: "linux"}},
+				}
+
+				Expect(options.Validate()).To(MatchError(SatisfyAll(
+					ContainSubstring("BuildImage must not be empty"),
+					ContainSubstring("RunImages must not be empty"),
+					ContainSubstring("RegistryURL must not be empty"),
+					ContainSubstring("lifecycle version and uri are mutually exclusive"),
+					ContainSubstring("buildpack 0: uri must not be empty"),
+					ContainSubstring("order group 0 is empty"),
+					ContainSubstring("order group 1: id must not be empty"),
+					ContainSubstring("target 0: os and arch must not be empty"),
+				)))
+			})
+
+			it("requires a stack id for the go assembler only", func() {
+				options.StackID = ""
+				Expect(options.Validate()).To(Succeed())
+
+				options.Assembler = utils.AssemblerGo
+				options.Lifecycle = utils.BuilderLifecycle{Version: "0.20.1"}
+				Expect(options.Validate()).To(MatchError(ContainSubstring("StackID must not be empty")))
+			})
+
+			it("requires either an archive or an image for every run image", func() {
+				options.RunImages = append(options.RunImages, utils.BuilderRunImage{}, utils.BuilderRunImage{Archive: "run.oci", Image: "run"})
+
+				Expect(options.Validate()).To(MatchError(SatisfyAll(
+					ContainSubstring("run image 1: exactly one of archive or image must be set"),
+					ContainSubstring("run image 2: exactly one of archive or image must be set"),
+				)))
+			})
+
+			it("requires extensions for order-extensions", func() {
+				options.OrderExtensions = []utils.OrderGroup{{{ID: "paketo-community/ubi-nodejs-extension"}}}
+
+				Expect(options.Validate()).To(MatchError("builder options: order-extensions require extensions"))
+			})
+
+			it("rejects unknown assemblers", func() {
+				options.Assembler = "buildah"
+
+				Expect(options.Validate()).To(MatchError(`builder options: assembler "buildah" must be one of ["pack" "go"]`))
+			})
+
+			it("requires a lifecycle and valid platforms for the go assembler", func() {
+				options.Assembler = utils.AssemblerGo
+				options.Platforms = []string{"linux/amd64", "linux"}
+
+				Expect(options.Validate()).To(MatchError(SatisfyAll(
+					ContainSubstring("the go assembler requires a lifecycle version or uri"),
+					ContainSubstring(`platforms: platform "linux" must be of the form os/arch[/variant]`),
+				)))
+			})
+
+			it("requires the arch placeholder in the lifecycle uri of several platforms", func() {
+				options.Assembler = utils.AssemblerGo
+				options.Platforms = []string{"linux/amd64", "linux/arm64"}
+				options.Lifecycle.URI = "lifecycle.tgz"
+
+				Expect(options.Validate()).To(MatchError("builder options: the lifecycle uri must contain {arch} to create builders for several platforms"))
+			})
+
+			it("rejects the arch placeholder for pack", func() {
+				options.Lifecycle.URI = "lifecycle-{arch}.tgz"
+
+				Expect(options.Validate()).To(MatchError("builder options: pack does not expand {arch} in the lifecycle uri"))
+			})
+		})
+	})
+}
@@ -0,0 +1,288 @@
+package utils
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// BuilderCache hands out the builder it generated earlier for options with
+// the same BuilderKey, so that suites sharing a builder only push and create
+// it once.
+type BuilderCache struct {
+	mutex   sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	done    chan struct{}
+	builder GeneratedBuilder
+	err     error
+}
+
+func NewBuilderCache() *BuilderCache {
+	return &BuilderCache{entries: map[string]*cacheEntry{}}
+}
+
+// Generate returns the cached builder of options, generating it with
+// GenerateBuilder when the cache has none. Concurrent calls for the same key
+// wait for the first one, and failures are not cached.
+func (c *BuilderCache) Generate(options BuilderOptions) (GeneratedBuilder, error) {
+	err := options.Validate()
+	if err != nil {
+		return GeneratedBuilder{}, err
+	}
+
+	key, err := BuilderKey(options)
+	if err != nil {
+		return GeneratedBuilder{}, err
+	}
+
+	c.mutex.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &cacheEntry{done: make(chan struct{})}
+		c.entries[key] = entry
+	}
+	c.mutex.Unlock()
+
+	if ok {
+		<-entry.done
+		return entry.builder, entry.err
+	}
+
+	entry.builder, entry.err = generateBuilder(options, fmt.Sprintf("builder-%s", key[:12]))
+	if entry.err != nil {
+		c.mutex.Lock()
+		delete(c.entries, key)
+		c.mutex.Unlock()
+	}
+	close(entry.done)
+
+	return entry.builder, entry.err
+}
+
+// GC empties the cache and calls remove for every builder it generated.
+// Images that several builders share, such as a build image, are only part
+// of the first builder remove is called with.
+func (c *BuilderCache) GC(remove func(GeneratedBuilder) error) error {
+	c.mutex.Lock()
+	entries := c.entries
+	c.entries = map[string]*cacheEntry{}
+	c.mutex.Unlock()
+
+	var keys []string
+	for key, entry := range entries {
+		<-entry.done
+		if entry.err == nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	seen := map[string]bool{}
+	unseen := func(ref string) bool {
+		if ref == "" || seen[ref] {
+			return false
+		}
+		seen[ref] = true
+		return true
+	}
+
+	var errs []error
+	for _, key := range keys {
+		builder := entries[key].builder
+
+		var unique GeneratedBuilder
+		if unseen(builder.Builder) {
+			unique.Builder = builder.Builder
+		}
+		if unseen(builder.BuildImage) {
+			unique.BuildImage = builder.BuildImage
+		}
+		for _, runImage := range builder.RunImages {
+			if unseen(runImage) {
+				unique.RunImages = append(unique.RunImages, runImage)
+			}
+		}
+
+		err := remove(unique)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", builder.Builder, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// BuilderKey is the sha256 of options along with the digests of the build
+// and run images, the buildpacks, the extensions and the lifecycle archive
+// they refer to, rather than their locations. Published run images and
+// docker:// buildpackages are resolved in their registry, and released
+// lifecycles are identified by their version.
+func BuilderKey(options BuilderOptions) (string, error) {
+	var (
+		key struct {
+			Options    BuilderOptions
+			BuildImage string
+			RunImages  []string
+			Buildpacks []string
+			Extensions []string
+			Lifecycle  []string
+		}
+		errs []error
+	)
+
+	digest, err := archiveDigest(options.BuildImage)
+	errs = append(errs, err)
+	key.BuildImage = digest
+	options.BuildImage = ""
+
+	runImages := make([]BuilderRunImage, len(options.RunImages))
+	for index, runImage := range options.RunImages {
+		if runImage.Archive != "" {
+			digest, err = archiveDigest(runImage.Archive)
+		} else {
+			digest, err = referenceDigest(runImage.Image)
+		}
+		errs = append(errs, err)
+		key.RunImages = append(key.RunImages, digest)
+
+		runImages[index] = BuilderRunImage{Mirrors: runImage.Mirrors}
+	}
+	options.RunImages = runImages
+
+	modules := []struct {
+		modules *[]BuilderModule
+		digests *[]string
+	}{
+		{modules: &options.Buildpacks, digests: &key.Buildpacks},
+		{modules: &options.Extensions, digests: &key.Extensions},
+	}
+	for _, m := range modules {
+		stripped := make([]BuilderModule, len(*m.modules))
+		for index, module := range *m.modules {
+			digest, err := moduleDigest(module.URI)
+			errs = append(errs, err)
+			*m.digests = append(*m.digests, digest)
+
+			stripped[index] = BuilderModule{ID: module.ID, Version: module.Version}
+		}
+		*m.modules = stripped
+	}
+
+	if options.Lifecycle.URI != "" {
+		platforms, err := options.platforms()
+		errs = append(errs, err)
+
+		uris := map[string]bool{}
+		for _, platform := range platforms {
+			uri, err := lifecycleURI(options.Lifecycle, platform)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			if !uris[uri] {
+				uris[uri] = true
+				digest, err := moduleDigest(uri)
+				errs = append(errs, err)
+				key.Lifecycle = append(key.Lifecycle, digest)
+			}
+		}
+		options.Lifecycle.URI = ""
+	}
+
+	err = errors.Join(errs...)
+	if err != nil {
+		return "", fmt.Errorf("builder key: %w", err)
+	}
+
+	key.Options = options
+	content, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// archiveDigest returns the digest of the index.json of the OCI archive at
+// path, which addresses all of its content, without extracting it.
+func archiveDigest(archive string) (string, error) {
+	file, err := os.Open(archive)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	reader := tar.NewReader(file)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("%s has no index.json", archive)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", archive, err)
+		}
+
+		if path.Clean(header.Name) == "index.json" {
+			digest, _, err := v1.SHA256(reader)
+			if err != nil {
+				return "", err
+			}
+			return digest.String(), nil
+		}
+	}
+}
+
+// moduleDigest returns the digest of the buildpackage image of a docker://
+// URI, or of the files of the directory or tarball at uri.
+func moduleDigest(uri string) (string, error) {
+	if strings.HasPrefix(uri, DockerTransport) {
+		return referenceDigest(strings.TrimPrefix(uri, DockerTransport))
+	}
+
+	entries, err := readURI(uri)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", uri, err)
+	}
+
+	hash := sha256.New()
+	for _, entry := range entries {
+		fmt.Fprintf(hash, "%s %c %o %s %d\n", entry.header.Name, entry.header.Typeflag, entry.header.Mode, entry.header.Linkname, len(entry.content))
+		hash.Write(entry.content)
+	}
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// referenceDigest resolves ref in its registry.
+func referenceDigest(ref string) (string, error) {
+	reference, err := name.ParseReference(ref)
+	if err != nil {
+		return "", err
+	}
+
+	descriptor, err := remote.Head(reference, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+
+	return descriptor.Digest.String(), nil
+}
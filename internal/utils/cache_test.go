@@ -0,0 +1,216 @@
+package utils_test
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/paketo-community/ubi-base-stack/internal/utils"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testCache(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		dir     string
+		server  *httptest.Server
+		denied  bool
+		options utils.BuilderOptions
+	)
+
+	writeFiles := func(root string, files map[string]string) {
+		for path, content := range files {
+			Expect(os.MkdirAll(filepath.Join(root, filepath.Dir(path)), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(root, path), []byte(content), 0755)).To(Succeed())
+		}
+	}
+
+	writeImage := func(path string, user string) {
+		image, err := mutate.ConfigFile(mutate.MediaType(empty.Image, types.OCIManifestSchema1), &v1.ConfigFile{
+			OS:           "linux",
+			Architecture: "amd64",
+			Config: v1.Config{
+				Env:    []string{"CNB_USER_ID=1002", "CNB_GROUP_ID=1000"},
+				Labels: map[string]string{utils.StackIDLabel: "io.buildpacks.stacks.ubi8"},
+				User:   user,
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		index, err := utils.SinglePlatformIndex(image)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(utils.WriteArchive(path, index)).To(Succeed())
+	}
+
+	it.Before(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "cache")
+		Expect(err).NotTo(HaveOccurred())
+
+		denied = false
+		handler := registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if denied {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			handler.ServeHTTP(w, r)
+		}))
+
+		writeImage(filepath.Join(dir, "build.oci"), "1002:1000")
+		writeImage(filepath.Join(dir, "run.oci"), "1002:1000")
+
+		writeFiles(filepath.Join(dir, "lifecycle"), map[string]string{
+			"lifecycle.toml": `[apis]
+[apis.buildpack]
+  supported = ["0.10"]
+[apis.platform]
+  supported = ["0.12"]
+
+[lifecycle]
+  version = "0.17.2"
+`,
+			"lifecycle/lifecycle": "lifecycle",
+		})
+
+		writeFiles(filepath.Join(dir, "go-dist"), map[string]string{
+			"buildpack.toml": `api = "0.7"
+
+[buildpack]
+  id = "paketo-buildpacks/go-dist"
+  version = "2.6.0"
+
+[[stacks]]
+  id = "*"
+`,
+			"bin/build": "build",
+		})
+
+		options = utils.BuilderOptions{
+			RegistryURL: strings.TrimPrefix(server.URL, "http://"),
+			Assembler:   utils.AssemblerGo,
+			StackID:     "io.buildpacks.stacks.ubi8",
+			Description: "ubi8 builder",
+			BuildImage:  filepath.Join(dir, "build.oci"),
+			RunImages:   []utils.BuilderRunImage{{Archive: filepath.Join(dir, "run.oci")}},
+			Buildpacks:  []utils.BuilderModule{{URI: filepath.Join(dir, "go-dist")}},
+			Order:       []utils.OrderGroup{{{ID: "paketo-buildpacks/go-dist"}}},
+			Lifecycle:   utils.BuilderLifecycle{URI: filepath.Join(dir, "lifecycle")},
+		}
+	})
+
+	it.After(func() {
+		server.Close()
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	context("BuilderKey", func() {
+		it("depends on the content of the inputs rather than their location", func() {
+			key, err := utils.BuilderKey(options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key).To(HaveLen(64))
+
+			moved := filepath.Join(dir, "moved")
+			Expect(os.Mkdir(moved, os.ModePerm)).To(Succeed())
+			for _, name := range []string{"build.oci", "run.oci", "go-dist", "lifecycle"} {
+				Expect(os.Rename(filepath.Join(dir, name), filepath.Join(moved, name))).To(Succeed())
+			}
+
+			options.BuildImage = filepath.Join(moved, "build.oci")
+			options.RunImages = []utils.BuilderRunImage{{Archive: filepath.Join(moved, "run.oci")}}
+			options.Buildpacks = []utils.BuilderModule{{URI: filepath.Join(moved, "go-dist")}}
+			options.Lifecycle.URI = filepath.Join(moved, "lifecycle")
+			Expect(utils.BuilderKey(options)).To(Equal(key))
+		})
+
+		it("changes with the images, the modules and the options", func() {
+			key, err := utils.BuilderKey(options)
+			Expect(err).NotTo(HaveOccurred())
+
+			writeImage(filepath.Join(dir, "run.oci"), "1003:1000")
+			runKey, err := utils.BuilderKey(options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runKey).NotTo(Equal(key))
+
+			writeFiles(filepath.Join(dir, "go-dist"), map[string]string{"bin/build": "changed"})
+			buildpackKey, err := utils.BuilderKey(options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buildpackKey).NotTo(Equal(runKey))
+
+			options.Description = "another builder"
+			Expect(utils.BuilderKey(options)).NotTo(Equal(buildpackKey))
+		})
+
+		context("failure cases", func() {
+			it("reports inputs that cannot be read", func() {
+				options.RunImages = []utils.BuilderRunImage{{Archive: filepath.Join(dir, "missing.oci")}}
+
+				_, err := utils.BuilderKey(options)
+				Expect(err).To(MatchError(os.ErrNotExist))
+			})
+		})
+	})
+
+	context("BuilderCache", func() {
+		it("generates a builder once per key and removes the images of every builder once", func() {
+			cache := utils.NewBuilderCache()
+
+			generated, err := cache.Generate(options)
+			Expect(err).NotTo(HaveOccurred())
+
+			key, err := utils.BuilderKey(options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(generated.Builder).To(Equal(options.RegistryURL + "/builder-" + key[:12]))
+
+			Expect(cache.Generate(options)).To(Equal(generated))
+
+			options.Description = "another builder"
+			other, err := cache.Generate(options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(other.Builder).NotTo(Equal(generated.Builder))
+			Expect(other.BuildImage).To(Equal(generated.BuildImage))
+			Expect(other.RunImages).To(Equal(generated.RunImages))
+
+			var removed []string
+			Expect(cache.GC(func(builder utils.GeneratedBuilder) error {
+				removed = append(removed, builder.Builder, builder.BuildImage)
+				removed = append(removed, builder.RunImages...)
+				return nil
+			})).To(Succeed())
+			Expect(removed).To(ContainElements(generated.Builder, other.Builder, generated.BuildImage, generated.RunImages[0]))
+			Expect(removed).To(HaveLen(5))
+
+			Expect(cache.GC(func(utils.GeneratedBuilder) error {
+				t.Fatal("the cache is empty")
+				return nil
+			})).To(Succeed())
+		})
+
+		context("failure cases", func() {
+			it("does not cache failures", func() {
+				cache := utils.NewBuilderCache()
+
+				denied = true
+				_, err := cache.Generate(options)
+				Expect(err).To(HaveOccurred())
+
+				denied = false
+				_, err = cache.Generate(options)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+}
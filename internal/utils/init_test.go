@@ -0,0 +1,17 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitUtils(t *testing.T) {
+	suite := spec.New("utils", spec.Report(report.Terminal{}))
+	suite("Assemble", testAssemble)
+	suite("Builder", testBuilder)
+	suite("Cache", testCache)
+	suite("Inspect", testInspect)
+	suite.Run(t)
+}
@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// BuilderInfo is what a builder image declares in its labels, whether pack
+// or AssembleBuilder created it.
+type BuilderInfo struct {
+	Description string `json:"description"`
+	StackID     string `json:"stackID,omitempty"`
+	// Target is the platform of the builder image and the distro of its
+	// build image.
+	Target Target `json:"target"`
+	// RunImages are the run images of the builder, the default one first.
+	RunImages       []RunImageMetadata `json:"runImages"`
+	Buildpacks      []InspectedModule  `json:"buildpacks"`
+	Extensions      []InspectedModule  `json:"extensions,omitempty"`
+	Order           []OrderTable       `json:"order"`
+	OrderExtensions []OrderTable       `json:"orderExtensions,omitempty"`
+	Lifecycle       LifecycleMetadata  `json:"lifecycle"`
+	CreatedBy       CreatorMetadata    `json:"createdBy"`
+}
+
+// InspectedModule is a buildpack or extension of a builder along with what
+// its layer declares.
+type InspectedModule struct {
+	ModuleInfo
+	API     string        `json:"api"`
+	Stacks  []ModuleStack `json:"stacks,omitempty"`
+	Targets []Target      `json:"targets,omitempty"`
+	Order   []OrderTable  `json:"order,omitempty"`
+}
+
+// SupportedBuildpackAPIs returns the buildpack APIs of the lifecycle,
+// falling back to the single API of builders that predate the list.
+func (b BuilderInfo) SupportedBuildpackAPIs() []string {
+	if supported := b.Lifecycle.APIs.Buildpack.Supported; len(supported) > 0 {
+		return supported
+	}
+
+	if b.Lifecycle.API.BuildpackVersion == "" {
+		return nil
+	}
+
+	return []string{b.Lifecycle.API.BuildpackVersion}
+}
+
+// SupportedPlatformAPIs returns the platform APIs of the lifecycle, falling
+// back to the single API of builders that predate the list.
+func (b BuilderInfo) SupportedPlatformAPIs() []string {
+	if supported := b.Lifecycle.APIs.Platform.Supported; len(supported) > 0 {
+		return supported
+	}
+
+	if b.Lifecycle.API.PlatformVersion == "" {
+		return nil
+	}
+
+	return []string{b.Lifecycle.API.PlatformVersion}
+}
+
+// InspectBuilder reads the labels of builder.
+func InspectBuilder(builder v1.Image) (BuilderInfo, error) {
+	config, err := builder.ConfigFile()
+	if err != nil {
+		return BuilderInfo{}, err
+	}
+
+	labels := config.Config.Labels
+	if _, ok := labels[BuilderMetadataLabel]; !ok {
+		return BuilderInfo{}, fmt.Errorf("not a builder, the %s label is missing", BuilderMetadataLabel)
+	}
+
+	var (
+		metadata        BuilderMetadata
+		buildpackLayers ModuleLayers
+		extensionLayers ModuleLayers
+		info            BuilderInfo
+	)
+
+	decode := []struct {
+		label string
+		value any
+	}{
+		{label: BuilderMetadataLabel, value: &metadata},
+		{label: BuildpackLayersLabel, value: &buildpackLayers},
+		{label: BuildpackOrderLabel, value: &info.Order},
+		{label: ExtensionLayersLabel, value: &extensionLayers},
+		{label: ExtensionOrderLabel, value: &info.OrderExtensions},
+	}
+	for _, d := range decode {
+		content, ok := labels[d.label]
+		if !ok {
+			continue
+		}
+
+		err = json.Unmarshal([]byte(content), d.value)
+		if err != nil {
+			return BuilderInfo{}, fmt.Errorf("failed to parse the %s label: %w", d.label, err)
+		}
+	}
+
+	info.Description = metadata.Description
+	info.StackID = labels[StackIDLabel]
+	info.Lifecycle = metadata.Lifecycle
+	info.CreatedBy = metadata.CreatedBy
+
+	info.RunImages = metadata.Images
+	if len(info.RunImages) == 0 && metadata.Stack.RunImage.Image != "" {
+		info.RunImages = []RunImageMetadata{metadata.Stack.RunImage}
+	}
+
+	info.Target = Target{OS: config.OS, Arch: config.Architecture, ArchVariant: config.Variant}
+	for _, keys := range [][2]string{{DistroNameLabel, DistroVersionLabel}, {StackDistroNameLabel, StackDistroVersionLabel}} {
+		if distro := labels[keys[0]]; distro != "" {
+			info.Target.Distros = []TargetDistro{{Name: distro, Version: labels[keys[1]]}}
+			break
+		}
+	}
+
+	info.Buildpacks = inspectModules(metadata.Buildpacks, buildpackLayers)
+	info.Extensions = inspectModules(metadata.Extensions, extensionLayers)
+
+	return info, nil
+}
+
+// InspectPublishedBuilder reads the labels of the builder at ref, for
+// platform when ref is an index.
+func InspectPublishedBuilder(ref string, platform v1.Platform) (BuilderInfo, error) {
+	reference, err := name.ParseReference(ref)
+	if err != nil {
+		return BuilderInfo{}, err
+	}
+
+	image, err := remote.Image(reference, remote.WithPlatform(platform), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return BuilderInfo{}, fmt.Errorf("failed to fetch %s: %w", ref, err)
+	}
+
+	info, err := InspectBuilder(image)
+	if err != nil {
+		return BuilderInfo{}, fmt.Errorf("%s: %w", ref, err)
+	}
+
+	return info, nil
+}
+
+// InspectBuilderArchive reads the labels of the builder for platform in the
+// OCI archive at path.
+func InspectBuilderArchive(path string, platform v1.Platform) (BuilderInfo, error) {
+	archive, err := OpenArchive(path)
+	if err != nil {
+		return BuilderInfo{}, err
+	}
+	defer archive.Close()
+
+	image, err := archive.Image(platform)
+	if err != nil {
+		return BuilderInfo{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	info, err := InspectBuilder(image)
+	if err != nil {
+		return BuilderInfo{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return info, nil
+}
+
+// inspectModules lists the modules of the layers label, sorted by id and
+// version, along with their name and homepage in the builder metadata.
+func inspectModules(infos []ModuleInfo, layers ModuleLayers) []InspectedModule {
+	var modules []InspectedModule
+	for id, versions := range layers {
+		for version, layer := range versions {
+			info := ModuleInfo{ID: id, Version: version, Name: layer.Name, Homepage: layer.Homepage}
+			for _, i := range infos {
+				if i.ID == id && i.Version == version {
+					info = i
+				}
+			}
+
+			modules = append(modules, InspectedModule{
+				ModuleInfo: info,
+				API:        layer.API,
+				Stacks:     layer.Stacks,
+				Targets:    layer.Targets,
+				Order:      layer.Order,
+			})
+		}
+	}
+
+	sort.Slice(modules, func(i, j int) bool {
+		if modules[i].ID != modules[j].ID {
+			return modules[i].ID < modules[j].ID
+		}
+		return modules[i].Version < modules[j].Version
+	})
+
+	return modules
+}
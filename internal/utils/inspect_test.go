@@ -0,0 +1,208 @@
+package utils_test
+
+import (
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/paketo-community/ubi-base-stack/internal/utils"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testInspect(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		builder v1.Image
+		labels  map[string]string
+	)
+
+	it.Before(func() {
+		// labels as pack builder create writes them
+		labels = map[string]string{
+			utils.StackIDLabel:            "io.buildpacks.stacks.ubi8",
+			utils.StackDistroNameLabel:    "rhel",
+			utils.StackDistroVersionLabel: "8.10",
+			utils.BuilderMetadataLabel: `{
+				"description": "ubi8 builder",
+				"stack": {"runImage": {"image": "registry.example.com/run:latest", "mirrors": ["docker.io/paketocommunity/run-ubi-base"]}},
+				"images": [
+					{"image": "registry.example.com/run:latest", "mirrors": ["docker.io/paketocommunity/run-ubi-base"]},
+					{"image": "registry.example.com/run-nodejs-20:latest"}
+				],
+				"buildpacks": [
+					{"id": "paketo-buildpacks/nodejs", "version": "7.2.1", "homepage": "https://github.com/paketo-buildpacks/nodejs"}
+				],
+				"extensions": [{"id": "paketo-community/ubi-nodejs-extension", "version": "1.0.0"}],
+				"lifecycle": {
+					"version": "0.17.2",
+					"api": {"buildpack": "0.2", "platform": "0.3"},
+					"apis": {
+						"buildpack": {"deprecated": [], "supported": ["0.2", "0.10"]},
+						"platform": {"deprecated": [], "supported": ["0.3", "0.12"]}
+					}
+				},
+				"createdBy": {"name": "Pack CLI", "version": "0.32.1"}
+			}`,
+			utils.BuildpackLayersLabel: `{
+				"paketo-buildpacks/node-engine": {"3.0.0": {"api": "0.7", "targets": [{"os": "linux", "arch": "amd64"}], "layerDiffID": "sha256:aaaa", "name": "Node Engine"}},
+				"paketo-buildpacks/nodejs": {"7.2.1": {"api": "0.7", "order": [{"group": [{"id": "paketo-buildpacks/node-engine", "version": "3.0.0"}]}], "layerDiffID": "sha256:bbbb", "homepage": "https://github.com/paketo-buildpacks/nodejs"}}
+			}`,
+			utils.BuildpackOrderLabel:  `[{"group": [{"id": "paketo-buildpacks/nodejs", "version": "7.2.1"}]}]`,
+			utils.ExtensionLayersLabel: `{"paketo-community/ubi-nodejs-extension": {"1.0.0": {"api": "0.9", "layerDiffID": "sha256:cccc"}}}`,
+			utils.ExtensionOrderLabel:  `[{"group": [{"id": "paketo-community/ubi-nodejs-extension", "version": "1.0.0", "optional": true}]}]`,
+		}
+
+		var err error
+		builder, err = mutate.ConfigFile(empty.Image, &v1.ConfigFile{
+			OS:           "linux",
+			Architecture: "amd64",
+			Config:       v1.Config{Labels: labels},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	context("InspectBuilder", func() {
+		it("reads the labels of the builder", func() {
+			info, err := utils.InspectBuilder(builder)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(info).To(Equal(utils.BuilderInfo{
+				Description: "ubi8 builder",
+				StackID:     "io.buildpacks.stacks.ubi8",
+				Target: utils.Target{
+					OS:      "linux",
+					Arch:    "amd64",
+					Distros: []utils.TargetDistro{{Name: "rhel", Version: "8.10"}},
+				},
+				RunImages: []utils.RunImageMetadata{
+					{Image: "registry.example.com/run:latest", Mirrors: []string{"docker.io/paketocommunity/run-ubi-base"}},
+					{Image: "registry.example.com/run-nodejs-20:latest"},
+				},
+				Buildpacks: []utils.InspectedModule{
+					{
+						ModuleInfo: utils.ModuleInfo{ID: "paketo-buildpacks/node-engine", Version: "3.0.0", Name: "Node Engine"},
+						API:        "0.7",
+						Targets:    []utils.Target{{OS: "linux", Arch: "amd64"}},
+					},
+					{
+						ModuleInfo: utils.ModuleInfo{ID: "paketo-buildpacks/nodejs", Version: "7.2.1", Homepage: "https://github.com/paketo-buildpacks/nodejs"},
+						API:        "0.7",
+						Order:      []utils.OrderTable{{Group: utils.OrderGroup{{ID: "paketo-buildpacks/node-engine", Version: "3.0.0"}}}},
+					},
+				},
+				Extensions: []utils.InspectedModule{
+					{ModuleInfo: utils.ModuleInfo{ID: "paketo-community/ubi-nodejs-extension", Version: "1.0.0"}, API: "0.9"},
+				},
+				Order: []utils.OrderTable{
+					{Group: utils.OrderGroup{{ID: "paketo-buildpacks/nodejs", Version: "7.2.1"}}},
+				},
+				OrderExtensions: []utils.OrderTable{
+					{Group: utils.OrderGroup{{ID: "paketo-community/ubi-nodejs-extension", Version: "1.0.0", Optional: true}}},
+				},
+				Lifecycle: utils.LifecycleMetadata{
+					Version: "0.17.2",
+					API:     utils.LifecycleAPI{BuildpackVersion: "0.2", PlatformVersion: "0.3"},
+					APIs: utils.LifecycleAPIs{
+						Buildpack: utils.APISet{Deprecated: []string{}, Supported: []string{"0.2", "0.10"}},
+						Platform:  utils.APISet{Deprecated: []string{}, Supported: []string{"0.3", "0.12"}},
+					},
+				},
+				CreatedBy: utils.CreatorMetadata{Name: "Pack CLI", Version: "0.32.1"},
+			}))
+
+			Expect(info.SupportedBuildpackAPIs()).To(Equal([]string{"0.2", "0.10"}))
+			Expect(info.SupportedPlatformAPIs()).To(Equal([]string{"0.3", "0.12"}))
+		})
+
+		it("falls back to the stack run image and lifecycle APIs of older builders", func() {
+			labels[utils.BuilderMetadataLabel] = `{
+				"stack": {"runImage": {"image": "registry.example.com/run:latest"}},
+				"lifecycle": {"version": "0.9.3", "api": {"buildpack": "0.2", "platform": "0.3"}}
+			}`
+			delete(labels, utils.ExtensionLayersLabel)
+			delete(labels, utils.ExtensionOrderLabel)
+
+			builder, err := mutate.Config(builder, v1.Config{Labels: labels})
+			Expect(err).NotTo(HaveOccurred())
+
+			info, err := utils.InspectBuilder(builder)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.RunImages).To(Equal([]utils.RunImageMetadata{{Image: "registry.example.com/run:latest"}}))
+			Expect(info.Extensions).To(BeEmpty())
+			Expect(info.OrderExtensions).To(BeEmpty())
+			Expect(info.SupportedBuildpackAPIs()).To(Equal([]string{"0.2"}))
+			Expect(info.SupportedPlatformAPIs()).To(Equal([]string{"0.3"}))
+		})
+
+		context("failure cases", func() {
+			it("rejects images that are not builders", func() {
+				_, err := utils.InspectBuilder(empty.Image)
+				Expect(err).To(MatchError("not a builder, the io.buildpacks.builder.metadata label is missing"))
+			})
+
+			it("reports malformed labels", func() {
+				labels[utils.BuildpackOrderLabel] = "{"
+
+				builder, err := mutate.Config(builder, v1.Config{Labels: labels})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = utils.InspectBuilder(builder)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse the io.buildpacks.buildpack.order label")))
+			})
+		})
+	})
+
+	context("InspectBuilderArchive", func() {
+		it("reads the builder of the platform from an OCI archive", func() {
+			dir, err := os.MkdirTemp("", "inspect")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			index, err := utils.SinglePlatformIndex(builder)
+			Expect(err).NotTo(HaveOccurred())
+
+			path := filepath.Join(dir, "builder.oci")
+			Expect(utils.WriteArchive(path, index)).To(Succeed())
+
+			info, err := utils.InspectBuilderArchive(path, v1.Platform{OS: "linux", Architecture: "amd64"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Lifecycle.Version).To(Equal("0.17.2"))
+
+			_, err = utils.InspectBuilderArchive(path, v1.Platform{OS: "linux", Architecture: "arm64"})
+			Expect(err).To(MatchError(ContainSubstring(`no image for platform linux/arm64, found ["linux/amd64"]`)))
+
+			_, err = utils.InspectBuilderArchive(filepath.Join(dir, "missing.oci"), v1.Platform{OS: "linux", Architecture: "amd64"})
+			Expect(err).To(MatchError(os.ErrNotExist))
+		})
+	})
+
+	context("InspectPublishedBuilder", func() {
+		it("reads the builder of the platform from a registry", func() {
+			server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+			defer server.Close()
+
+			ref := strings.TrimPrefix(server.URL, "http://") + "/builder:latest"
+			index, err := utils.SinglePlatformIndex(builder)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(remote.WriteIndex(must(name.ParseReference(ref)), index)).To(Succeed())
+
+			info, err := utils.InspectPublishedBuilder(ref, v1.Platform{OS: "linux", Architecture: "amd64"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.StackID).To(Equal("io.buildpacks.stacks.ubi8"))
+			Expect(info.RunImages).To(HaveLen(2))
+		})
+	})
+}
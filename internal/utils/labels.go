@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Labels of a builder image, as read by pack and the lifecycle.
+const (
+	BuilderMetadataLabel = "io.buildpacks.builder.metadata"
+	BuildpackLayersLabel = "io.buildpacks.buildpack.layers"
+	BuildpackOrderLabel  = "io.buildpacks.buildpack.order"
+	ExtensionLayersLabel = "io.buildpacks.extension.layers"
+	ExtensionOrderLabel  = "io.buildpacks.extension.order"
+
+	// BuildpackageLabel identifies the top-level buildpack of a buildpackage
+	// image.
+	BuildpackageLabel = "io.buildpacks.buildpackage.metadata"
+	// StackIDLabel is the stack id of the build and run images.
+	StackIDLabel = "io.buildpacks.stack.id"
+
+	// Distro labels of the build and run images, the stack ones being those
+	// of older platform APIs.
+	DistroNameLabel         = "io.buildpacks.base.distro.name"
+	DistroVersionLabel      = "io.buildpacks.base.distro.version"
+	StackDistroNameLabel    = "io.buildpacks.stack.distro.name"
+	StackDistroVersionLabel = "io.buildpacks.stack.distro.version"
+)
+
+// BuilderMetadata is the io.buildpacks.builder.metadata label.
+type BuilderMetadata struct {
+	Description string        `json:"description"`
+	Stack       StackMetadata `json:"stack"`
+	// Images are the run images of the builder, the first one being the
+	// one of Stack.
+	Images     []RunImageMetadata `json:"images,omitempty"`
+	Buildpacks []ModuleInfo       `json:"buildpacks"`
+	Extensions []ModuleInfo       `json:"extensions,omitempty"`
+	Lifecycle  LifecycleMetadata  `json:"lifecycle"`
+	CreatedBy  CreatorMetadata    `json:"createdBy"`
+}
+
+// StackMetadata locates the default run image of a builder for platforms
+// that predate multiple run images.
+type StackMetadata struct {
+	RunImage RunImageMetadata `json:"runImage"`
+}
+
+type RunImageMetadata struct {
+	Image   string   `toml:"image" json:"image"`
+	Mirrors []string `toml:"mirrors,omitempty" json:"mirrors,omitempty"`
+}
+
+// ModuleInfo identifies a buildpack or extension of a builder.
+type ModuleInfo struct {
+	ID       string `json:"id"`
+	Version  string `json:"version"`
+	Name     string `json:"name,omitempty"`
+	Homepage string `json:"homepage,omitempty"`
+}
+
+// LifecycleMetadata describes the lifecycle of a builder. API holds the
+// oldest supported APIs for platforms that predate APIs.
+type LifecycleMetadata struct {
+	Version string        `json:"version"`
+	API     LifecycleAPI  `json:"api"`
+	APIs    LifecycleAPIs `json:"apis"`
+}
+
+type LifecycleAPI struct {
+	BuildpackVersion string `json:"buildpack"`
+	PlatformVersion  string `json:"platform"`
+}
+
+// LifecycleAPIs are the buildpack and platform APIs a lifecycle implements,
+// as declared by its lifecycle.toml.
+type LifecycleAPIs struct {
+	Buildpack APISet `toml:"buildpack" json:"buildpack"`
+	Platform  APISet `toml:"platform" json:"platform"`
+}
+
+type APISet struct {
+	Deprecated []string `toml:"deprecated" json:"deprecated"`
+	Supported  []string `toml:"supported" json:"supported"`
+}
+
+type CreatorMetadata struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// ModuleLayers is the io.buildpacks.buildpack.layers or
+// io.buildpacks.extension.layers label, keyed by module id and version.
+type ModuleLayers map[string]map[string]ModuleLayer
+
+// ModuleLayer describes the layer holding one version of a module.
+type ModuleLayer struct {
+	API         string        `json:"api"`
+	Stacks      []ModuleStack `json:"stacks,omitempty"`
+	Targets     []Target      `json:"targets,omitempty"`
+	Order       []OrderTable  `json:"order,omitempty"`
+	LayerDiffID string        `json:"layerDiffID"`
+	Homepage    string        `json:"homepage,omitempty"`
+	Name        string        `json:"name,omitempty"`
+}
+
+// ModuleStack is a stack a buildpack declares it supports.
+type ModuleStack struct {
+	ID     string   `toml:"id" json:"id"`
+	Mixins []string `toml:"mixins" json:"mixins,omitempty"`
+}
+
+// Target is a platform a builder or module supports. Empty fields of the
+// targets of a module match any value.
+type Target struct {
+	OS          string         `toml:"os,omitempty" json:"os,omitempty"`
+	Arch        string         `toml:"arch,omitempty" json:"arch,omitempty"`
+	ArchVariant string         `toml:"variant,omitempty" json:"variant,omitempty"`
+	Distros     []TargetDistro `toml:"distros,omitempty" json:"distros,omitempty"`
+}
+
+// Platform returns the os/arch[/variant] of the target.
+func (t Target) Platform() string {
+	return strings.TrimSuffix(strings.Join([]string{t.OS, t.Arch, t.ArchVariant}, "/"), "/")
+}
+
+// String returns the platform of the target followed by its distributions,
+// e.g. linux/amd64 (rhel 8).
+func (t Target) String() string {
+	if len(t.Distros) == 0 {
+		return t.Platform()
+	}
+
+	var distros []string
+	for _, distro := range t.Distros {
+		distros = append(distros, strings.TrimSpace(distro.Name+" "+distro.Version))
+	}
+
+	return fmt.Sprintf("%s (%s)", t.Platform(), strings.Join(distros, ", "))
+}
+
+type TargetDistro struct {
+	Name    string `toml:"name" json:"name"`
+	Version string `toml:"version,omitempty" json:"version,omitempty"`
+}
+
+// OrderTable is an [[order]] table of a descriptor, or an entry of the order
+// labels.
+type OrderTable struct {
+	Group OrderGroup `toml:"group" json:"group"`
+}
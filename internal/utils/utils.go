@@ -2,51 +2,215 @@ package utils
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/uuid"
 
 	"github.com/paketo-buildpacks/occam"
 	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-community/ubi-base-stack/internal/images"
 )
 
-func GenerateBuilder(buildImage string, runImage string, registryUrl string) (buildImageUrl string, runImageUrl string, builderImageUrl string, err error) {
+// GeneratedBuilder locates the images GenerateBuilder pushed.
+type GeneratedBuilder struct {
+	BuildImage string
+	// RunImages are the run images pushed from archives, in the order of the
+	// options.
+	RunImages []string
+	Builder   string
+}
 
-	buildImageID := fmt.Sprintf("build-image-%s", uuid.NewString())
-	buildImageUrl, err = PushFileToLocalRegistry(buildImage, registryUrl, buildImageID)
+// GenerateBuilderFromStacks creates a builder for distro that pairs the build
+// image of buildStack with the run images of runStacks, see
+// StackBuilderOptions.
+func GenerateBuilderFromStacks(root string, distro images.Distro, buildStack images.StackImages, runStacks []images.StackImages, options BuilderOptions) (GeneratedBuilder, error) {
+	options, err := StackBuilderOptions(root, distro, buildStack, runStacks, options)
 	if err != nil {
-		return "", "", "", err
+		return GeneratedBuilder{}, err
+	}
+
+	return GenerateBuilder(options)
+}
+
+// StackBuilderOptions fills in the stack fields of options to pair the build
+// image of buildStack with the run images of runStacks, using the OCI
+// archives found under root. The first run stack provides the default run
+// image, and the targets default to the distro on every platform of the
+// options.
+func StackBuilderOptions(root string, distro images.Distro, buildStack images.StackImages, runStacks []images.StackImages, options BuilderOptions) (BuilderOptions, error) {
+	if !buildStack.CreateBuildImage {
+		return BuilderOptions{}, fmt.Errorf("stack %q does not provide a build image", buildStack.Name)
+	}
+
+	options.StackID = distro.StackID
+	options.BuildImage = buildStack.BuildArchive(root)
+	options.RunImages = nil
+	for _, runStack := range runStacks {
+		if buildStack.Distro != runStack.Distro {
+			return BuilderOptions{}, fmt.Errorf("stack %q is built on %s but the build image of %q is built on %s", runStack.Name, runStack.Distro, buildStack.Name, buildStack.Distro)
+		}
+
+		options.RunImages = append(options.RunImages, BuilderRunImage{Archive: runStack.RunArchive(root)})
 	}
 
-	runImageID := fmt.Sprintf("run-image-%s", uuid.NewString())
-	runImageUrl, err = PushFileToLocalRegistry(runImage, registryUrl, runImageID)
+	if len(options.Targets) == 0 {
+		platforms, err := options.platforms()
+		if err != nil {
+			return BuilderOptions{}, err
+		}
+
+		for _, platform := range platforms {
+			options.Targets = append(options.Targets, DistroTarget(distro, platform))
+		}
+	}
+
+	return options, nil
+}
+
+// DistroTarget is the target of a builder for distro on platform.
+func DistroTarget(distro images.Distro, platform v1.Platform) Target {
+	return Target{
+		OS:          platform.OS,
+		Arch:        platform.Architecture,
+		ArchVariant: platform.Variant,
+		Distros:     []TargetDistro{{Name: distro.OSName, Version: distro.OSVersion}},
+	}
+}
+
+// GenerateBuilder pushes the build and run image archives of options to its
+// registry and publishes a builder made of them, with pack or in process
+// depending on options.Assembler. The archives are pushed under names derived
+// from their digest, so pushing the same archive again reuses the image.
+func GenerateBuilder(options BuilderOptions) (GeneratedBuilder, error) {
+	err := options.Validate()
 	if err != nil {
-		return "", "", "", err
+		return GeneratedBuilder{}, err
+	}
+
+	return generateBuilder(options, fmt.Sprintf("builder-%s", uuid.NewString()))
+}
+
+// generateBuilder generates the builder of valid options under builderName.
+func generateBuilder(options BuilderOptions, builderName string) (GeneratedBuilder, error) {
+	push := func(archive string, prefix string) (string, error) {
+		digest, err := archiveDigest(archive)
+		if err != nil {
+			return "", err
+		}
+
+		imageID := fmt.Sprintf("%s-%s", prefix, strings.TrimPrefix(digest, "sha256:")[:12])
+		if options.Assembler == AssemblerGo {
+			ref := fmt.Sprintf("%s/%s", options.RegistryURL, imageID)
+			return ref, PushArchive(archive, ref)
+		}
+
+		ref, err := PushFileToLocalRegistry(archive, options.RegistryURL, imageID)
+		if err != nil {
+			return "", fmt.Errorf("failed to push %s: %w\n%s", archive, err, ref)
+		}
+		return ref, nil
+	}
+
+	// the go assembler checks the extensions while assembling
+	if options.Assembler != AssemblerGo && len(options.Extensions) > 0 {
+		err := checkExtensions(options)
+		if err != nil {
+			return GeneratedBuilder{}, err
+		}
+	}
+
+	var (
+		generated GeneratedBuilder
+		err       error
+	)
+	generated.BuildImage, err = push(options.BuildImage, "build-image")
+	if err != nil {
+		return GeneratedBuilder{}, err
+	}
+
+	runImages := make([]BuilderRunImage, len(options.RunImages))
+	for index, runImage := range options.RunImages {
+		runImages[index] = runImage
+		if runImage.Archive == "" {
+			continue
+		}
+
+		url, err := push(runImage.Archive, "run-image")
+		if err != nil {
+			return GeneratedBuilder{}, err
+		}
+		generated.RunImages = append(generated.RunImages, url)
+
+		runImages[index].Archive = ""
+		runImages[index].Image = fmt.Sprintf("%s:latest", url)
+	}
+	options.RunImages = runImages
+
+	generated.Builder = fmt.Sprintf("%s/%s", options.RegistryURL, builderName)
+	if options.Assembler == AssemblerGo {
+		err = assembleBuilder(options, generated.Builder)
+	} else {
+		err = createBuilder(options, generated.BuildImage, generated.Builder)
+	}
+	if err != nil {
+		return GeneratedBuilder{}, err
+	}
+
+	err = VerifyPublishedBuilder(generated.Builder, generated.BuildImage, generated.RunImages)
+	if err != nil {
+		return GeneratedBuilder{}, err
+	}
+
+	return generated, nil
+}
+
+// createBuilder creates the builder with pack.
+func createBuilder(options BuilderOptions, buildImageUrl, builderImageUrl string) error {
+	builderToml, err := options.BuilderToml(buildImageUrl)
+	if err != nil {
+		return err
 	}
 
 	// Creating builder file
 	builderConfigFile, err := os.CreateTemp("", "builder.toml")
 	if err != nil {
-		return "", "", "", err
+		return err
 	}
 
 	builderConfigFilepath := builderConfigFile.Name()
 
-	_, err = fmt.Fprintf(builderConfigFile, `
-			[stack]
-			  id = "io.buildpacks.stacks.ubi8"
-			  build-image = "%s:latest"
-			  run-image = "%s:latest"
-			`, buildImageUrl, runImageUrl)
+	_, err = builderConfigFile.Write(builderToml)
+	if err != nil {
+		return err
+	}
 
+	err = builderConfigFile.Close()
 	if err != nil {
-		return "", "", "", err
+		return err
 	}
 
-	// naming builder and pushing it to registry with pack cli
-	builderImageUrl = fmt.Sprintf("%s/builder-%s", registryUrl, uuid.NewString())
+	// pushing the builder to the registry with pack cli
+	args := []string{
+		"builder",
+		"create",
+		builderImageUrl,
+		fmt.Sprintf("--config=%s", builderConfigFilepath),
+		"--publish",
+	}
+
+	var labels []string
+	for key := range options.Labels {
+		labels = append(labels, key)
+	}
+	sort.Strings(labels)
+
+	for _, key := range labels {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", key, options.Labels[key]))
+	}
 
 	buf := bytes.NewBuffer(nil)
 
@@ -54,25 +218,31 @@ func GenerateBuilder(buildImage string, runImage string, registryUrl string) (bu
 	err = pack.Execute(pexec.Execution{
 		Stdout: buf,
 		Stderr: buf,
-		Args: []string{
-			"builder",
-			"create",
-			builderImageUrl,
-			fmt.Sprintf("--config=%s", builderConfigFilepath),
-			"--publish",
-		},
+		Args:   args,
 	})
 
 	if err != nil {
-		return "", "", "", err
+		return fmt.Errorf("failed to create builder: %w\n%s", err, buf.String())
 	}
 
-	err = os.RemoveAll(builderConfigFilepath)
+	return os.RemoveAll(builderConfigFilepath)
+}
+
+// assembleBuilder assembles a builder per platform in process on top of the
+// build image archive and pushes their index.
+func assembleBuilder(options BuilderOptions, builderImageUrl string) error {
+	archive, err := OpenArchive(options.BuildImage)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	builder, err := AssembleBuilderIndex(archive.Index, options)
 	if err != nil {
-		return "", "", "", err
+		return fmt.Errorf("failed to assemble builder: %w", err)
 	}
 
-	return buildImageUrl, runImageUrl, builderImageUrl, nil
+	return PublishIndex(builderImageUrl, builder)
 }
 
 func PushFileToLocalRegistry(filePath string, registryUrl string, imageName string) (string, error) {
@@ -110,49 +280,18 @@ func RemoveImages(docker occam.Docker, imageIDs []string) error {
 	return nil
 }
 
-func GetLifecycleImageID(docker occam.Docker, builderImageUrl string) (lifecycleImageID string, err error) {
-
-	lifecycleVersion, err := GetLifecycleVersion(builderImageUrl)
+// GetLifecycleImageID returns the lifecycle image matching the lifecycle of
+// the published builder, which pack pulls when building with it.
+func GetLifecycleImageID(builderImageUrl string) (string, error) {
+	platform, err := ParsePlatform("")
 	if err != nil {
 		return "", err
 	}
 
-	lifecycleImageID = fmt.Sprintf("buildpacksio/lifecycle:%s", lifecycleVersion)
-
-	return lifecycleImageID, nil
-}
-
-type Builder struct {
-	LocalInfo struct {
-		Lifecycle struct {
-			Version string `json:"version"`
-		} `json:"lifecycle"`
-	} `json:"remote_info"`
-}
-
-func GetLifecycleVersion(builderUrl string) (string, error) {
-	buf := bytes.NewBuffer(nil)
-	pack := pexec.NewExecutable("pack")
-	err := pack.Execute(pexec.Execution{
-		Stdout: buf,
-		Stderr: buf,
-		Args: []string{
-			"builder",
-			"inspect",
-			builderUrl,
-			"-o",
-			"json",
-		},
-	})
-
+	builder, err := InspectPublishedBuilder(builderImageUrl, platform)
 	if err != nil {
 		return "", err
 	}
 
-	var builder Builder
-	err = json.Unmarshal([]byte(buf.String()), &builder)
-	if err != nil {
-		return "", err
-	}
-	return builder.LocalInfo.Lifecycle.Version, nil
+	return fmt.Sprintf("buildpacksio/lifecycle:%s", builder.Lifecycle.Version), nil
 }
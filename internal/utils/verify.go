@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// VerifyBuilder checks that every builder of the builder index is built on
+// the image of the same platform of the build index, and that the run images
+// provide the platforms of the builder. The builder must start with the
+// layers of its build image and, when it records BaseDigestAnnotation, name
+// its digest. Run images are keyed by reference for the errors.
+func VerifyBuilder(builder v1.ImageIndex, build v1.ImageIndex, runImages map[string]v1.ImageIndex) error {
+	manifest, err := builder.IndexManifest()
+	if err != nil {
+		return err
+	}
+
+	if len(manifest.Manifests) == 0 {
+		return errors.New("builder index is empty")
+	}
+
+	var errs []error
+	for _, descriptor := range manifest.Manifests {
+		if descriptor.Platform == nil {
+			errs = append(errs, fmt.Errorf("builder %s has no platform", descriptor.Digest))
+			continue
+		}
+
+		image, err := builder.Image(descriptor.Digest)
+		if err != nil {
+			return err
+		}
+
+		err = verifyPlatform(image, *descriptor.Platform, build, runImages)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("builder for %s: %w", descriptor.Platform.String(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func verifyPlatform(builder v1.Image, platform v1.Platform, build v1.ImageIndex, runImages map[string]v1.ImageIndex) error {
+	config, err := builder.ConfigFile()
+	if err != nil {
+		return err
+	}
+
+	if actual := config.Platform(); actual == nil || !actual.Satisfies(platform) {
+		return fmt.Errorf("image is built for %s", config.Platform().String())
+	}
+
+	buildImage, err := imageForPlatform(build, platform)
+	if err != nil {
+		return fmt.Errorf("build image: %w", err)
+	}
+
+	buildDigest, err := buildImage.Digest()
+	if err != nil {
+		return err
+	}
+
+	manifest, err := builder.Manifest()
+	if err != nil {
+		return err
+	}
+
+	if base, ok := manifest.Annotations[BaseDigestAnnotation]; ok && base != buildDigest.String() {
+		return fmt.Errorf("base image is %s but the build image is %s", base, buildDigest)
+	}
+
+	buildConfig, err := buildImage.ConfigFile()
+	if err != nil {
+		return err
+	}
+
+	builderDiffIDs := config.RootFS.DiffIDs
+	buildDiffIDs := buildConfig.RootFS.DiffIDs
+	if len(builderDiffIDs) < len(buildDiffIDs) {
+		return fmt.Errorf("image does not contain the layers of the build image %s", buildDigest)
+	}
+
+	for index, diffID := range buildDiffIDs {
+		if builderDiffIDs[index] != diffID {
+			return fmt.Errorf("image does not contain the layers of the build image %s", buildDigest)
+		}
+	}
+
+	var errs []error
+	for ref, runImage := range runImages {
+		_, err := imageForPlatform(runImage, platform)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("run image %s: %w", ref, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// VerifyPublishedBuilder runs VerifyBuilder against published images. Any of
+// them may be a single image rather than an index.
+func VerifyPublishedBuilder(builderRef string, buildRef string, runRefs []string) error {
+	builder, err := fetchIndex(builderRef)
+	if err != nil {
+		return err
+	}
+
+	build, err := fetchIndex(buildRef)
+	if err != nil {
+		return err
+	}
+
+	runImages := map[string]v1.ImageIndex{}
+	for _, ref := range runRefs {
+		runImages[ref], err = fetchIndex(ref)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = VerifyBuilder(builder, build, runImages)
+	if err != nil {
+		return fmt.Errorf("%s: %w", builderRef, err)
+	}
+
+	return nil
+}
+
+// fetchIndex reads the index at ref, wrapping a single image in an index.
+func fetchIndex(ref string) (v1.ImageIndex, error) {
+	reference, err := name.ParseReference(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptor, err := remote.Get(reference, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", ref, err)
+	}
+
+	if descriptor.MediaType.IsIndex() {
+		return descriptor.ImageIndex()
+	}
+
+	image, err := descriptor.Image()
+	if err != nil {
+		return nil, err
+	}
+
+	return SinglePlatformIndex(image)
+}
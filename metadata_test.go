@@ -36,10 +36,9 @@ func testMetadata(t *testing.T, context spec.G, it spec.S) {
 	})
 
 	it("builds base stack", func() {
-		var (
-			buildReleaseDate time.Time
-			runReleaseDate   time.Time
-		)
+		// release dates are keyed by distro
+		buildReleaseDates := map[string]time.Time{}
+		runReleaseDates := map[string]time.Time{}
 
 		for _, imageInfo := range settings.ImagesJson.StackImages {
 
@@ -47,15 +46,19 @@ func testMetadata(t *testing.T, context spec.G, it spec.S) {
 				continue
 			}
 
-			by("confirming that the build image is correct", func() {
+			stack := settings.Stacks[imageInfo.Name]
+			distro := settings.ImagesJson.Distros[imageInfo.Distro]
+			metadataLabel, err := imageInfo.MetadataLabel()
+			Expect(err).NotTo(HaveOccurred())
+
+			by(fmt.Sprintf("confirming that the %s build image is correct", imageInfo.Distro), func() {
 				index, manifests, err := getImageIndexAndManifests(tmpDir, filepath.Join(root, imageInfo.OutputDir, "build.oci"))
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(manifests).To(HaveLen(4))
-				Expect(manifests[0].Platform).To(Equal(&v1.Platform{
-					OS:           "linux",
-					Architecture: "amd64",
-				}))
+				Expect(manifests).To(HaveLen(len(stack.Platforms)))
+				platform, err := v1.ParsePlatform(stack.Platforms[0])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(manifests[0].Platform).To(Equal(platform))
 
 				image, err := index.Image(manifests[0].Digest)
 				Expect(err).NotTo(HaveOccurred())
@@ -64,46 +67,51 @@ func testMetadata(t *testing.T, context spec.G, it spec.S) {
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(file.Config.Labels).To(SatisfyAll(
-					HaveKeyWithValue("io.buildpacks.stack.id", "io.buildpacks.stacks.ubi8"),
-					HaveKeyWithValue("io.buildpacks.stack.description", "base build ubi8 image to support buildpacks"),
-					HaveKeyWithValue("io.buildpacks.stack.distro.name", "rhel"),
-					HaveKeyWithValue("io.buildpacks.stack.distro.version", MatchRegexp(`8\.\d+`)),
-					HaveKeyWithValue("io.buildpacks.stack.homepage", "https://github.com/paketo-community/ubi-base-stack"),
-					HaveKeyWithValue("io.buildpacks.stack.maintainer", "Paketo Community"),
-					HaveKeyWithValue("io.buildpacks.stack.metadata", MatchJSON("{}")),
+					HaveKeyWithValue("io.buildpacks.stack.id", stack.ID),
+					HaveKeyWithValue("io.buildpacks.stack.description", stack.Build.Description),
+					HaveKeyWithValue("io.buildpacks.stack.distro.name", distro.OSName),
+					HaveKeyWithValue("io.buildpacks.stack.distro.version", MatchRegexp(distro.VersionPattern())),
+					HaveKeyWithValue("io.buildpacks.stack.homepage", stack.Homepage),
+					HaveKeyWithValue("io.buildpacks.stack.maintainer", stack.Maintainer),
+					HaveKeyWithValue("io.buildpacks.stack.metadata", MatchJSON(metadataLabel)),
 				))
 
-				buildReleaseDate, err = time.Parse(time.RFC3339, file.Config.Labels["io.buildpacks.stack.released"])
+				buildReleaseDate, err := time.Parse(time.RFC3339, file.Config.Labels["io.buildpacks.stack.released"])
 				Expect(err).NotTo(HaveOccurred())
 				Expect(buildReleaseDate).NotTo(BeZero())
+				buildReleaseDates[imageInfo.Distro] = buildReleaseDate
 
 				Expect(image).To(SatisfyAll(
-					HaveFileWithContent("/etc/group", ContainSubstring("cnb:x:1000:")),
-					HaveFileWithContent("/etc/passwd", ContainSubstring("cnb:x:1002:1000::/home/cnb:/bin/bash")),
+					HaveFileWithContent("/etc/group", ContainSubstring(fmt.Sprintf("cnb:x:%d:", stack.Build.GID))),
+					HaveFileWithContent("/etc/passwd", ContainSubstring(fmt.Sprintf("cnb:x:%d:%d::/home/cnb:%s", stack.Build.UID, stack.Build.GID, stack.Build.Shell))),
 					HaveDirectory("/home/cnb"),
 				))
 
-				Expect(file.Config.User).To(Equal("1002:1000"))
+				Expect(file.Config.User).To(Equal(fmt.Sprintf("%d:%d", stack.Build.UID, stack.Build.GID)))
 
 				Expect(file.Config.Env).To(ContainElements(
-					"CNB_USER_ID=1002",
-					"CNB_GROUP_ID=1000",
-					"CNB_STACK_ID=io.buildpacks.stacks.ubi8",
+					fmt.Sprintf("CNB_USER_ID=%d", stack.Build.UID),
+					fmt.Sprintf("CNB_GROUP_ID=%d", stack.Build.GID),
+					fmt.Sprintf("CNB_STACK_ID=%s", stack.ID),
 				))
 			})
 		}
 
 		for _, imageInfo := range settings.ImagesJson.StackImages {
+			stack := settings.Stacks[imageInfo.Name]
+			distro := settings.ImagesJson.Distros[imageInfo.Distro]
+			metadataLabel, err := imageInfo.MetadataLabel()
+			Expect(err).NotTo(HaveOccurred())
+
 			by(fmt.Sprintf("confirming that the run %s image is correct", imageInfo.Name), func() {
 
 				index, manifests, err := getImageIndexAndManifests(tmpDir, filepath.Join(root, imageInfo.OutputDir, "run.oci"))
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(manifests).To(HaveLen(4))
-				Expect(manifests[0].Platform).To(Equal(&v1.Platform{
-					OS:           "linux",
-					Architecture: "amd64",
-				}))
+				Expect(manifests).To(HaveLen(len(stack.Platforms)))
+				platform, err := v1.ParsePlatform(stack.Platforms[0])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(manifests[0].Platform).To(Equal(platform))
 
 				image, err := index.Image(manifests[0].Digest)
 				Expect(err).NotTo(HaveOccurred())
@@ -112,44 +120,34 @@ func testMetadata(t *testing.T, context spec.G, it spec.S) {
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(file.Config.Labels).To(SatisfyAll(
-					HaveKeyWithValue("io.buildpacks.stack.id", "io.buildpacks.stacks.ubi8"),
-					HaveKeyWithValue("io.buildpacks.stack.distro.name", "rhel"),
-					HaveKeyWithValue("io.buildpacks.stack.distro.version", MatchRegexp(`8\.\d+`)),
-					HaveKeyWithValue("io.buildpacks.stack.homepage", "https://github.com/paketo-community/ubi-base-stack"),
-					HaveKeyWithValue("io.buildpacks.stack.maintainer", "Paketo Community"),
-					HaveKeyWithValue("io.buildpacks.stack.metadata", MatchJSON("{}")),
+					HaveKeyWithValue("io.buildpacks.stack.id", stack.ID),
+					HaveKeyWithValue("io.buildpacks.stack.description", stack.Run.Description),
+					HaveKeyWithValue("io.buildpacks.stack.distro.name", distro.OSName),
+					HaveKeyWithValue("io.buildpacks.stack.distro.version", MatchRegexp(distro.VersionPattern())),
+					HaveKeyWithValue("io.buildpacks.stack.homepage", stack.Homepage),
+					HaveKeyWithValue("io.buildpacks.stack.maintainer", stack.Maintainer),
+					HaveKeyWithValue("io.buildpacks.stack.metadata", MatchJSON(metadataLabel)),
 				))
 
-				if imageInfo.Name == "default" {
-					Expect(file.Config.Labels).To(SatisfyAll(
-						HaveKeyWithValue("io.buildpacks.stack.description", fmt.Sprintf("base %s ubi8 image to support buildpacks", imageInfo.RunImage)),
-					))
-
-				} else {
-					Expect(file.Config.Labels).To(SatisfyAll(
-						HaveKeyWithValue("io.buildpacks.stack.description", fmt.Sprintf("ubi8 %s image to support buildpacks", imageInfo.Name)),
-					))
-				}
-
 				runImageReleaseDate, err := time.Parse(time.RFC3339, file.Config.Labels["io.buildpacks.stack.released"])
 				Expect(err).NotTo(HaveOccurred())
 				Expect(runImageReleaseDate).NotTo(BeZero())
 
 				// Store the release date to compare if the date is the same as the build image on later steps
-				if imageInfo.Name == "default" {
-					runReleaseDate = runImageReleaseDate
+				if imageInfo.Name == BuildStacks[imageInfo.Distro].Name {
+					runReleaseDates[imageInfo.Distro] = runImageReleaseDate
 				}
 
-				Expect(file.Config.User).To(Equal("1001:1000"))
+				Expect(file.Config.User).To(Equal(fmt.Sprintf("%d:%d", stack.Run.UID, stack.Run.GID)))
 
 				Expect(image).To(SatisfyAll(
-					HaveFileWithContent("/etc/group", ContainSubstring("cnb:x:1000:")),
-					HaveFileWithContent("/etc/passwd", ContainSubstring("cnb:x:1001:1000::/home/cnb:/bin/bash")),
+					HaveFileWithContent("/etc/group", ContainSubstring(fmt.Sprintf("cnb:x:%d:", stack.Run.GID))),
+					HaveFileWithContent("/etc/passwd", ContainSubstring(fmt.Sprintf("cnb:x:%d:%d::/home/cnb:%s", stack.Run.UID, stack.Run.GID, stack.Run.Shell))),
 					HaveDirectory("/home/cnb"),
 				))
 
 				Expect(image).To(HaveFileWithContent("/etc/os-release", SatisfyAll(
-					ContainLines(MatchRegexp(`PRETTY_NAME=\"Red Hat Enterprise Linux 8\.\d+ \(Ootpa\)\"`)),
+					ContainLines(MatchRegexp(distro.PrettyNamePattern())),
 					ContainSubstring(`HOME_URL="https://github.com/paketo-community/ubi-base-stack"`),
 					ContainSubstring(`SUPPORT_URL="https://github.com/paketo-community/ubi-base-stack/blob/main/README.md"`),
 					ContainSubstring(`BUG_REPORT_URL="https://github.com/paketo-community/ubi-base-stack/issues/new"`),
@@ -157,7 +155,7 @@ func testMetadata(t *testing.T, context spec.G, it spec.S) {
 			})
 		}
 
-		Expect(runReleaseDate).To(Equal(buildReleaseDate))
+		Expect(runReleaseDates).To(Equal(buildReleaseDates))
 	})
 }
 
@@ -65,24 +65,36 @@ function main() {
   tools::install
 
   if [ -f "${IMAGES_JSON}" ]; then
-    # we need to copy images.json for inclusion in the build image
-    defaultStackPath=$(jq -r '.images[] | select(.name == "default") | .config_dir' "${IMAGES_JSON}")
-    cp $IMAGES_JSON $ROOT_DIR/$defaultStackPath/images.json
+    # we need to copy images.json for inclusion in the build image of every
+    # distro
+    stack_tools images | jq -r 'select(.create_build_image) | .config_dir' | while read -r buildStackPath; do
+      cp "${IMAGES_JSON}" "${ROOT_DIR}/${buildStackPath}/images.json"
+    done
   fi
 
   # if stack or build argument is provided but not both, then throw an error
   if [[ -n "${stack_dir_name}" && ! -n "${build_dir_name}" ]] || [[ ! -n "${stack_dir_name}" && -n "${build_dir_name}" ]]; then
     util::print::error "Both stack-dir and build-dir must be provided"
   elif [[ -n "${stack_dir_name}" && -n "${build_dir_name}" ]]; then
-    stack::create "${ROOT_DIR}/${stack_dir_name}" "${ROOT_DIR}/${build_dir_name}" "${flags[@]}"
+    name=""
+    if [ -f "${IMAGES_JSON}" ]; then
+      name=$(stack_tools images | jq -r --arg dir "${stack_dir_name}" 'select(.config_dir == $dir) | .name')
+    fi
+
+    if [[ -n "${name}" ]]; then
+      image::create "${name}" "${stack_dir_name}" "${build_dir_name}" "${flags[@]}"
+    else
+      stack::create "${ROOT_DIR}/${stack_dir_name}" "${ROOT_DIR}/${build_dir_name}" "" "${flags[@]}"
+    fi
   elif [ -f "${IMAGES_JSON}" ]; then
-    jq -c '.images[]' "${IMAGES_JSON}" | while read -r image; do
+    stack_tools images | while read -r image; do
+      name=$(echo "${image}" | jq -r '.name')
       config_dir=$(echo "${image}" | jq -r '.config_dir')
       output_dir=$(echo "${image}" | jq -r '.output_dir')
-      stack::create "${ROOT_DIR}/${config_dir}" "${ROOT_DIR}/${output_dir}" "${flags[@]}"
+      image::create "${name}" "${config_dir}" "${output_dir}" "${flags[@]}"
     done
   else
-    stack::create "${ROOT_DIR}/stack" "${ROOT_DIR}/build" "${flags[@]}"
+    stack::create "${ROOT_DIR}/stack" "${ROOT_DIR}/build" "" "${flags[@]}"
   fi
 }
 
@@ -101,26 +113,62 @@ OPTIONS
 USAGE
 }
 
+# Runs the Go stack tooling of this repository, which owns the parsing and
+# validation of stacks/images.json.
+function stack_tools() {
+  go -C "${ROOT_DIR}" run ./cmd/stack-tools "${@}"
+}
+
 function tools::install() {
   util::tools::jam::install \
     --directory "${BIN_DIR}"
 }
 
+# Creates the stack of an images.json entry, labelling its images with the
+# lifecycle metadata of the entry.
+function image::create() {
+  local name config_dir output_dir metadata_label
+
+  name="${1}"
+  config_dir="${2}"
+  output_dir="${3}"
+  shift 3
+
+  # warns about deprecated and expired variants without failing the build
+  stack_tools lifecycle --name "${name}"
+  metadata_label=$(stack_tools metadata-label --name "${name}")
+
+  stack::create "${ROOT_DIR}/${config_dir}" "${ROOT_DIR}/${output_dir}" "${name}" "${@}" \
+    --label "io.buildpacks.stack.metadata=${metadata_label}"
+}
+
+# Creates the stack described by the stack.toml in stack_dirpath. When name
+# is not empty, the args of the stack.toml are expanded against that
+# images.json entry.
 function stack::create() {
   local flags
-  local stack_dirpath build_dirpath
+  local stack_dirpath build_dirpath name
 
   stack_dirpath="${1}"
   shift
   build_dirpath="${1}"
   shift
+  name="${1}"
+  shift
 
   mkdir -p "${build_dirpath}"
 
   flags=("${@}")
 
+  # variants inherit from a parent descriptor and template their args, which
+  # jam does not understand
+  stack_tools materialize \
+    --config "${stack_dirpath}/stack.toml" \
+    --output "${build_dirpath}/stack.toml" \
+    --name "${name}"
+
   args=(
-      --config "${stack_dirpath}/stack.toml"
+      --config "${build_dirpath}/stack.toml"
       --build-output "${build_dirpath}/build.oci"
       --run-output "${build_dirpath}/run.oci"
     )
@@ -6,7 +6,6 @@ set -o pipefail
 readonly PROG_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
 readonly STACK_DIR="$(cd "${PROG_DIR}/.." && pwd)"
 readonly STACK_IMAGES_JSON_PATH="${STACK_DIR}/stacks/images.json"
-readonly INTEGRATION_JSON="${STACK_DIR}/integration.json"
 declare STACK_IMAGES
 
 # shellcheck source=SCRIPTDIR/.util/tools.sh
@@ -16,13 +15,15 @@ source "${PROG_DIR}/.util/tools.sh"
 source "${PROG_DIR}/.util/print.sh"
 
 function main() {
-  local clean token test_only_stacks validate_stack_builds
+  local clean token test_only_stacks validate_stack_builds profile lifecycles
   local registryPort registryPid localRegistry setupLocalRegistry
 
   help=""
   clean="false"
   token=""
   test_only_stacks=""
+  profile=""
+  lifecycles=""
   registryPid=""
   setupLocalRegistry=""
   validate_stack_builds="false"
@@ -49,6 +50,16 @@ function main() {
         shift 2
         ;;
 
+      --profile)
+        profile="${2}"
+        shift 2
+        ;;
+
+      --lifecycles)
+        lifecycles="${2}"
+        shift 2
+        ;;
+
       --validate-stack-builds)
         validate_stack_builds="true"
         shift 1
@@ -64,39 +75,36 @@ function main() {
     esac
   done
 
-  if [ -f "${STACK_IMAGES_JSON_PATH}" ]; then
-    all_stack_images=$(jq -c '.' "${STACK_IMAGES_JSON_PATH}")
-  else
-    # If there is no images.json file, fallback to the default image configuration
-    all_stack_images=$(jq -nc '{
-  "images": [
-    {
-      "config_dir": "stack",
-      "output_dir": "build",
-      "build_image": "build",
-      "run_image": "run",
-      "create_build_image": true
-    }
-  ]
-}' | jq -c '.')
+  ## The help only reads the entries of images.json, so that printing the
+  ## usage does not need a Go toolchain
+  if [[ "${help}" == "true" ]]; then
+    if [ -f "${STACK_IMAGES_JSON_PATH}" ]; then
+      STACK_IMAGES=$(jq -c '.images[]' "${STACK_IMAGES_JSON_PATH}")
+    else
+      STACK_IMAGES=$(default_stack_image)
+    fi
+    usage
+    exit 0
   fi
 
-  if [[ -n "${test_only_stacks}" ]]; then
-    filter_stacks=$(echo $test_only_stacks | jq -R 'split(" ")')
-    STACK_IMAGES=$(echo "${all_stack_images}" | \
-      jq --argjson names "$filter_stacks" -c \
-      '.images[] | select(.name | IN($names[]))')
+  if [ -f "${STACK_IMAGES_JSON_PATH}" ]; then
+    if [[ -n "${test_only_stacks}" ]]; then
+      # the acceptance suite applies the same selector through TEST_ONLY_STACKS,
+      # while the builders of the selected distros also need the archives of
+      # their build and default run stacks
+      STACK_IMAGES=$(stack_tools images --select "${test_only_stacks}" --with-builder-stacks)
+    else
+      STACK_IMAGES=$(stack_tools images)
+    fi
     export TEST_ONLY_STACKS="${test_only_stacks}"
   else
-    STACK_IMAGES=$(echo "${all_stack_images}" | jq -c '.images[]')
-    export TEST_ONLY_STACKS=""
-  fi
+    if [[ -n "${test_only_stacks}" ]]; then
+      util::print::error "--test-only-stacks selects entries of ${STACK_IMAGES_JSON_PATH}, which does not exist"
+    fi
 
-  ## The help is after the image parsing and filtering in order to output 
-  ## proper usage information based on the images that are available
-  if [[ "${help}" == "true" ]]; then
-    usage
-    exit 0
+    # If there is no images.json file, fallback to the default image configuration
+    STACK_IMAGES=$(default_stack_image)
+    export TEST_ONLY_STACKS=""
   fi
 
   tools::install "${token}"
@@ -126,9 +134,10 @@ function main() {
     util::print::title "Stack builds already exist..."
   fi
 
-  if [[ -f $INTEGRATION_JSON ]]; then
-    setupLocalRegistry=$(jq '.setup_local_registy' $INTEGRATION_JSON)
-  fi
+  # the acceptance suite reads the rest of the profile through STACK_PROFILE
+  setupLocalRegistry=$(stack_tools profile --profile "${profile}" | jq -r '.setup_local_registry // false')
+  export STACK_PROFILE="${profile}"
+  export STACK_LIFECYCLES="${lifecycles}"
 
   if [[ "${setupLocalRegistry}" == "true" ]]; then
     registryPort=$(get::random::port)
@@ -145,6 +154,23 @@ function main() {
   fi
 }
 
+# Runs the Go stack tooling of this repository, which owns the parsing and
+# validation of stacks/images.json.
+function stack_tools() {
+  go -C "${STACK_DIR}" run ./cmd/stack-tools "${@}"
+}
+
+# Prints the image configuration used when there is no images.json file.
+function default_stack_image() {
+  jq -nc '{
+  "config_dir": "stack",
+  "output_dir": "build",
+  "build_image": "build",
+  "run_image": "run",
+  "create_build_image": true
+}'
+}
+
 function join_by {
   local d=${1-} f=${2-}
   if shift 2; then
@@ -181,7 +207,12 @@ if they exist. Otherwise, first runs create.sh to create them.
 OPTIONS
   --clean          -c     Clears contents of stack output directory before running tests
   --token <token>  -t     Token used to download assets from GitHub (e.g. jam, pack, etc) (optional)
-  --test-only-stacks      Runs the tests of the stacks passed to this argument (e.g. java-8 nodejs-16) (optional)
+  --test-only-stacks      Runs the tests of the stacks matched by this selector (optional), e.g.
+                          "java-8 nodejs-20", "nodejs-* !nodejs-16", "type=java", "distro=ubi8"
+                          or "default-run". A selector that matches nothing is an error
+  --profile <name>        Profile of profiles.json to test against (e.g. local, staging), defaults to its default profile (optional)
+  --lifecycles <archives> Comma separated lifecycle archives to run the integration suites against, one builder each,
+                          writing lifecycle-report.json. Defaults to the lifecycles of the profile (optional)
   --validate-stack-builds Validates that the stack builds are present before running tests (optional)
   --help           -h     Prints the command usage
 USAGE
@@ -0,0 +1,29 @@
+package acceptance_test
+
+import (
+	"testing"
+
+	"github.com/paketo-community/ubi-base-stack/internal/images"
+	"github.com/sclevine/spec"
+)
+
+// variantSuite is an integration suite that runs against a single variant,
+// with the builder of its distro.
+type variantSuite struct {
+	name string
+	run  func(t *testing.T, context spec.G, it spec.S, stack images.StackImages, builder builderImages)
+}
+
+// variantSuites lists the integration suites run against every variant of a
+// type. A new variant is picked up through the type and runtime_version of
+// its images.json entry.
+var variantSuites = map[string][]variantSuite{
+	images.TypeBase: {
+		{name: "BuildpackIntegration", run: testBuildpackIntegration},
+	},
+	images.TypeJava: {},
+	images.TypeNodejs: {
+		{name: "BuildpackIntegration", run: testBuildpackIntegration},
+		{name: "NodejsStackIntegration", run: testNodejsStackIntegration},
+	},
+}